@@ -2,11 +2,21 @@ package main
 
 import (
 	"context"
+	"errors"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"scheduler/internal/handler"
+	"scheduler/internal/mailer"
 	"scheduler/internal/middleware"
+	"scheduler/internal/notify"
+	"scheduler/internal/oidc"
+	"scheduler/internal/realtime"
 	"scheduler/internal/repository/postgres"
+	"scheduler/internal/signing"
+	"syscall"
+	"time"
 
 	_ "scheduler/docs"
 
@@ -43,10 +53,20 @@ func (customValidator *CustomValidator) Validate(i interface{}) error {
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
 
+// @securityDefinitions.apikey APIKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key issued by an admin for service-to-service calls.
+
 func main() {
 	godotenv.Load()
 	e := echo.New()
 
+	// Cancelled on SIGINT or SIGTERM; stops the background workers and open
+	// request streams before the server shuts down
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	e.Use(echoMiddleware.CORSWithConfig(echoMiddleware.CORSConfig{
 		AllowOrigins: []string{
 			"*",
@@ -55,7 +75,7 @@ func main() {
 			"http://127.0.0.1:8080",
 		},
 		AllowMethods:     []string{echo.GET, echo.POST, echo.PUT, echo.DELETE, echo.PATCH, echo.OPTIONS},
-		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
+		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, "X-API-Key"},
 		AllowCredentials: true,
 		ExposeHeaders:    []string{echo.HeaderContentLength, echo.HeaderAuthorization},
 		MaxAge:           86400,
@@ -75,36 +95,100 @@ func main() {
 	defer storage.Close()
 
 	// Run migrations to create tables if they don't exist
-	if err := storage.RunMigrations(context.Background()); err != nil {
+	if err := storage.RunMigrations(ctx); err != nil {
 		e.Logger.Fatal("Failed to run migrations:", err)
 	}
 
+	// Re-import the course data in place, e.g. to pick up new seat counts
 	if os.Getenv("RESET_DB_ON_START") == "true" {
-		if err := storage.ResetCourseData(context.Background()); err != nil {
-			e.Logger.Fatal("Failed to reset course data:", err)
+		if err := storage.RefreshCatalog(ctx); err != nil {
+			e.Logger.Fatal("Failed to refresh course data:", err)
 		}
 	}
 
 	// Seed database with course data if empty
-	if err := storage.SeedDatabase(context.Background()); err != nil {
+	if err := storage.SeedDatabase(ctx); err != nil {
 		e.Logger.Warn("Failed to seed database:", err)
 	}
 
+	mail, err := mailer.FromEnv()
+	if err != nil {
+		e.Logger.Fatal("Failed to configure mail:", err)
+	}
+
+	// Alert students watching sections that regain free seats
+	channel, err := notify.ChannelFromEnv(mail)
+	if err != nil {
+		e.Logger.Fatal("Failed to configure notifications:", err)
+	}
+	go notify.NewSeatAlertWorker(storage, channel).Run(ctx)
+
+	// Access tokens are signed with keys kept in the database; load them, or
+	// create the first one, before serving any request
+	rotator := signing.NewRotator(storage)
+	if err := rotator.RunOnce(ctx); err != nil {
+		e.Logger.Fatal("Failed to load signing keys:", err)
+	}
+	go rotator.Run(ctx)
+
+	// Stream seat count changes published by any instance to SSE clients
+	seats := realtime.NewSeatBroadcaster()
+	go realtime.RunSeatListener(ctx, storage, seats)
+
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
 
 	e.GET("/api/health", func(c echo.Context) error {
 		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
 	})
 
+	handler.SetupJWKSRoutes(e)
 	handler.SetupCourseRoutes(e, storage)
+	handler.SetupSeatRoutes(e, storage, seats)
+
+	authMiddleware := middleware.JWTAuth(storage)
+
+	// Single sign-on is optional; without OIDC_ISSUER_URL its endpoints 404
+	var sso *oidc.Provider
+	if config, err := oidc.ConfigFromEnv(); err == nil {
+		sso = oidc.NewProvider(*config)
+	}
 
-	authMiddleware := middleware.JWTAuth()
-	handler.SetupStudentRoutes(e, storage, authMiddleware)
+	handler.SetupBuildingRoutes(e, storage, authMiddleware)
+	handler.SetupRoomRoutes(e, storage, authMiddleware)
+	handler.SetupStudentRoutes(e, storage, mail, authMiddleware)
+	handler.SetupAccountRoutes(e, storage, mail, authMiddleware)
+	handler.SetupTwoFactorRoutes(e, storage, authMiddleware)
+	handler.SetupOIDCRoutes(e, storage, sso)
+	handler.SetupProfileRoutes(e, storage, authMiddleware)
+	handler.SetupSessionRoutes(e, storage, authMiddleware)
 	handler.SetupScheduleRoutes(e, storage, authMiddleware)
+	handler.SetupBlockRoutes(e, storage, authMiddleware)
+	handler.SetupWatchlistRoutes(e, storage, authMiddleware)
+	handler.SetupNotificationRoutes(e, storage, authMiddleware)
+	handler.SetupPetitionRoutes(e, storage, authMiddleware)
+	handler.SetupShareRoutes(e, storage, authMiddleware)
+	handler.SetupGroupRoutes(e, storage, authMiddleware)
+	handler.SetupAdvisorRoutes(e, storage, authMiddleware)
+	handler.SetupAdminRoutes(e, storage, authMiddleware)
+	handler.SetupAPIKeyRoutes(e, storage, authMiddleware)
 
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
-	e.Logger.Fatal(e.Start(":" + port))
+	e.Server.BaseContext = func(net.Listener) context.Context { return ctx }
+
+	go func() {
+		if err := e.Start(":" + port); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			e.Logger.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		e.Logger.Error("Failed to shut down cleanly:", err)
+	}
 }
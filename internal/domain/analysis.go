@@ -0,0 +1,141 @@
+package domain
+
+// DefaultTightGapMinutes is the longest break between classes that is
+// flagged as possibly too short to walk between rooms
+const DefaultTightGapMinutes = 10
+
+type Gap struct {
+	DayOfWeek string `json:"day_of_week"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	Minutes   int    `json:"minutes"`
+	After     string `json:"after"`
+	Before    string `json:"before"`
+}
+
+type ClassBlock struct {
+	DayOfWeek string `json:"day_of_week"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	Minutes   int    `json:"minutes"`
+}
+
+type DayAnalysis struct {
+	DayOfWeek    string     `json:"day_of_week"`
+	FirstClass   string     `json:"first_class"`
+	LastClass    string     `json:"last_class"`
+	ClassMinutes int        `json:"class_minutes"`
+	IdleGaps     []Gap      `json:"idle_gaps"`
+	LongestBlock ClassBlock `json:"longest_block"`
+}
+
+type ScheduleAnalysis struct {
+	Days            []DayAnalysis `json:"days"`
+	FreeDays        []string      `json:"free_days"`
+	TotalClassHours float64       `json:"total_class_hours"`
+	LongestBlock    *ClassBlock   `json:"longest_block"`
+	TightGaps       []Gap         `json:"tight_gaps"`
+}
+
+// AnalyzeSchedule analyzes the class meetings on a schedule; busy blocks are
+// not classes and are ignored
+func AnalyzeSchedule(schedule ScheduleWithSections, tightGapMinutes int) ScheduleAnalysis {
+	var classes []TimeSlot
+	for _, slot := range schedule.Slots() {
+		if slot.SectionID != nil {
+			classes = append(classes, slot)
+		}
+	}
+	return AnalyzeSlots(classes, tightGapMinutes)
+}
+
+// AnalyzeSlots computes per-day class spans, idle gaps, the longest continuous
+// run of classes, free weekdays (Monday to Saturday) and transitions between
+// classes of at most tightGapMinutes. Overlapping or back-to-back slots count
+// as one continuous block.
+func AnalyzeSlots(slots []TimeSlot, tightGapMinutes int) ScheduleAnalysis {
+	slots = append([]TimeSlot(nil), slots...)
+	SortSlots(slots)
+
+	byDay := make(map[string][]TimeSlot)
+	for _, slot := range slots {
+		byDay[slot.DayOfWeek] = append(byDay[slot.DayOfWeek], slot)
+	}
+
+	analysis := ScheduleAnalysis{
+		Days:      []DayAnalysis{},
+		FreeDays:  []string{},
+		TightGaps: []Gap{},
+	}
+
+	totalMinutes := 0
+	for _, day := range Days {
+		daySlots := byDay[day]
+		if len(daySlots) == 0 {
+			if day != "Sunday" {
+				analysis.FreeDays = append(analysis.FreeDays, day)
+			}
+			continue
+		}
+
+		da := DayAnalysis{DayOfWeek: day, IdleGaps: []Gap{}}
+
+		blockStart, blockEnd := daySlots[0].Start, daySlots[0].End
+		lastLabel := daySlots[0].Label
+		closeBlock := func() {
+			da.ClassMinutes += blockEnd - blockStart
+			if blockEnd-blockStart > da.LongestBlock.Minutes {
+				da.LongestBlock = ClassBlock{
+					DayOfWeek: day,
+					StartTime: FormatClock(blockStart),
+					EndTime:   FormatClock(blockEnd),
+					Minutes:   blockEnd - blockStart,
+				}
+			}
+		}
+
+		for _, slot := range daySlots[1:] {
+			if slot.Start >= blockEnd && slot.Start-blockEnd <= tightGapMinutes {
+				analysis.TightGaps = append(analysis.TightGaps, newGap(day, blockEnd, slot.Start, lastLabel, slot.Label))
+			}
+
+			if slot.Start > blockEnd {
+				closeBlock()
+				da.IdleGaps = append(da.IdleGaps, newGap(day, blockEnd, slot.Start, lastLabel, slot.Label))
+				blockStart = slot.Start
+			}
+
+			if slot.End >= blockEnd {
+				blockEnd = slot.End
+				lastLabel = slot.Label
+			}
+		}
+		closeBlock()
+
+		da.FirstClass = FormatClock(daySlots[0].Start)
+		da.LastClass = FormatClock(blockEnd)
+		totalMinutes += da.ClassMinutes
+
+		if analysis.LongestBlock == nil || da.LongestBlock.Minutes > analysis.LongestBlock.Minutes {
+			longest := da.LongestBlock
+			analysis.LongestBlock = &longest
+		}
+
+		analysis.Days = append(analysis.Days, da)
+	}
+
+	analysis.TotalClassHours = float64(totalMinutes) / 60
+
+	return analysis
+}
+
+func newGap(day string, start, end int, after, before string) Gap {
+	return Gap{
+		DayOfWeek: day,
+		StartTime: FormatClock(start),
+		EndTime:   FormatClock(end),
+		Minutes:   end - start,
+		After:     after,
+		Before:    before,
+	}
+}
@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func slot(day string, start, end int, label string) TimeSlot {
+	return TimeSlot{DayOfWeek: day, Start: start, End: end, Label: label}
+}
+
+func TestAnalyzeSlotsEmpty(t *testing.T) {
+	analysis := AnalyzeSlots(nil, DefaultTightGapMinutes)
+
+	if len(analysis.Days) != 0 || len(analysis.TightGaps) != 0 {
+		t.Errorf("days = %v, tight gaps = %v, want none", analysis.Days, analysis.TightGaps)
+	}
+	if analysis.LongestBlock != nil {
+		t.Errorf("longest block = %+v, want nil", analysis.LongestBlock)
+	}
+	if analysis.TotalClassHours != 0 {
+		t.Errorf("total class hours = %v, want 0", analysis.TotalClassHours)
+	}
+
+	want := []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+	if !reflect.DeepEqual(analysis.FreeDays, want) {
+		t.Errorf("free days = %v, want %v", analysis.FreeDays, want)
+	}
+}
+
+func TestAnalyzeSlots(t *testing.T) {
+	slots := []TimeSlot{
+		// Out of order on purpose
+		slot("Monday", 780, 840, "HIST 100"),
+		slot("Monday", 540, 615, "CSCI 151"),
+		slot("Monday", 615, 690, "MATH 161"),
+		slot("Monday", 700, 760, "PHYS 161"),
+		slot("Wednesday", 540, 600, "CSCI 151"),
+		slot("Wednesday", 570, 630, "CSCI 152"),
+		slot("Wednesday", 580, 590, "CSCI 153"),
+		slot("Sunday", 600, 660, "WKND 101"),
+	}
+
+	analysis := AnalyzeSlots(slots, DefaultTightGapMinutes)
+
+	wantDays := []DayAnalysis{
+		{
+			DayOfWeek:    "Monday",
+			FirstClass:   "09:00:00",
+			LastClass:    "14:00:00",
+			ClassMinutes: 270,
+			IdleGaps: []Gap{
+				{DayOfWeek: "Monday", StartTime: "11:30:00", EndTime: "11:40:00", Minutes: 10, After: "MATH 161", Before: "PHYS 161"},
+				{DayOfWeek: "Monday", StartTime: "12:40:00", EndTime: "13:00:00", Minutes: 20, After: "PHYS 161", Before: "HIST 100"},
+			},
+			// Back-to-back classes are one block
+			LongestBlock: ClassBlock{DayOfWeek: "Monday", StartTime: "09:00:00", EndTime: "11:30:00", Minutes: 150},
+		},
+		{
+			DayOfWeek:    "Wednesday",
+			FirstClass:   "09:00:00",
+			LastClass:    "10:30:00",
+			ClassMinutes: 90,
+			IdleGaps:     []Gap{},
+			// Overlapping classes merge, including one inside another
+			LongestBlock: ClassBlock{DayOfWeek: "Wednesday", StartTime: "09:00:00", EndTime: "10:30:00", Minutes: 90},
+		},
+		{
+			DayOfWeek:    "Sunday",
+			FirstClass:   "10:00:00",
+			LastClass:    "11:00:00",
+			ClassMinutes: 60,
+			IdleGaps:     []Gap{},
+			LongestBlock: ClassBlock{DayOfWeek: "Sunday", StartTime: "10:00:00", EndTime: "11:00:00", Minutes: 60},
+		},
+	}
+	if !reflect.DeepEqual(analysis.Days, wantDays) {
+		t.Errorf("days =\n%+v\nwant\n%+v", analysis.Days, wantDays)
+	}
+
+	wantFree := []string{"Tuesday", "Thursday", "Friday", "Saturday"}
+	if !reflect.DeepEqual(analysis.FreeDays, wantFree) {
+		t.Errorf("free days = %v, want %v", analysis.FreeDays, wantFree)
+	}
+
+	// A back-to-back transition is tight; so is a 10 minute gap, but not 20
+	wantTight := []Gap{
+		{DayOfWeek: "Monday", StartTime: "10:15:00", EndTime: "10:15:00", Minutes: 0, After: "CSCI 151", Before: "MATH 161"},
+		{DayOfWeek: "Monday", StartTime: "11:30:00", EndTime: "11:40:00", Minutes: 10, After: "MATH 161", Before: "PHYS 161"},
+	}
+	if !reflect.DeepEqual(analysis.TightGaps, wantTight) {
+		t.Errorf("tight gaps =\n%+v\nwant\n%+v", analysis.TightGaps, wantTight)
+	}
+
+	if analysis.LongestBlock == nil || analysis.LongestBlock.Minutes != 150 || analysis.LongestBlock.DayOfWeek != "Monday" {
+		t.Errorf("longest block = %+v, want Monday's 150 minutes", analysis.LongestBlock)
+	}
+	if analysis.TotalClassHours != 7 {
+		t.Errorf("total class hours = %v, want 7", analysis.TotalClassHours)
+	}
+}
+
+func TestAnalyzeSlotsDayBoundaries(t *testing.T) {
+	slots := []TimeSlot{
+		slot("Monday", 1380, 1440, "Late"),
+		slot("Tuesday", 0, 60, "Early"),
+	}
+
+	analysis := AnalyzeSlots(slots, DefaultTightGapMinutes)
+
+	// Classes on either side of midnight are separate days, not a transition
+	if len(analysis.TightGaps) != 0 {
+		t.Errorf("tight gaps = %+v, want none across midnight", analysis.TightGaps)
+	}
+	if len(analysis.Days) != 2 {
+		t.Fatalf("got %d days, want 2", len(analysis.Days))
+	}
+	if day := analysis.Days[0]; day.LastClass != "24:00:00" || day.ClassMinutes != 60 {
+		t.Errorf("Monday = %+v, want a class ending at 24:00:00", day)
+	}
+	if day := analysis.Days[1]; day.FirstClass != "00:00:00" || len(day.IdleGaps) != 0 {
+		t.Errorf("Tuesday = %+v, want a class starting at 00:00:00", day)
+	}
+}
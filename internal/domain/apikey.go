@@ -0,0 +1,38 @@
+package domain
+
+import "time"
+
+// API key scopes
+const (
+	ScopeCatalogRead   = "catalog:read"
+	ScopeSchedulesRead = "schedules:read"
+	ScopeImportWrite   = "import:write"
+)
+
+var Scopes = []string{ScopeCatalogRead, ScopeSchedulesRead, ScopeImportWrite}
+
+// APIKey lets an internal service call the endpoints its scopes allow
+// without a user's password. The key itself is shown once, on creation.
+type APIKey struct {
+	ID         int        `db:"id" json:"id"`
+	Name       string     `db:"name" json:"name"`
+	Prefix     string     `db:"prefix" json:"prefix"`
+	Scopes     []string   `db:"scopes" json:"scopes"`
+	CreatedBy  *int       `db:"created_by" json:"created_by"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	ExpiresAt  *time.Time `db:"expires_at" json:"expires_at"`
+	LastUsedAt *time.Time `db:"last_used_at" json:"last_used_at"`
+	LastUsedIP *string    `db:"last_used_ip" json:"last_used_ip"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"revoked_at"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=catalog:read schedules:read import:write"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type CreateAPIKeyResponse struct {
+	Key    string `json:"key"`
+	APIKey APIKey `json:"api_key"`
+}
@@ -0,0 +1,45 @@
+package domain
+
+import "time"
+
+// Audit events
+const (
+	AuditLoginFailed = "login_failed"
+	AuditLoginLocked = "login_locked"
+
+	AuditTwoFactorFailed   = "two_factor_failed"
+	AuditTwoFactorEnabled  = "two_factor_enabled"
+	AuditTwoFactorDisabled = "two_factor_disabled"
+
+	AuditSSOLinked      = "sso_linked"
+	AuditSSOProvisioned = "sso_provisioned"
+
+	AuditAPIKeyCreated = "api_key_created"
+	AuditAPIKeyRevoked = "api_key_revoked"
+
+	AuditImpersonationStarted = "impersonation_started"
+	AuditImpersonatedRequest  = "impersonated_request"
+
+	AuditPasswordChanged = "password_changed"
+	AuditAccountDeleted  = "account_deleted"
+)
+
+// AuditEntry records a security-relevant event. StudentID is set when the
+// event can be tied to an account; Email keeps what was typed even when it
+// matches none.
+type AuditEntry struct {
+	ID        int       `db:"id" json:"id"`
+	Event     string    `db:"event" json:"event"`
+	StudentID *int      `db:"student_id" json:"student_id"`
+	Email     *string   `db:"email" json:"email"`
+	IPAddress *string   `db:"ip_address" json:"ip_address"`
+	UserAgent *string   `db:"user_agent" json:"user_agent"`
+	Detail    *string   `db:"detail" json:"detail"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type AuditFilter struct {
+	Event     string
+	StudentID int
+	Limit     int
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// BusyBlock is a personal recurring commitment (job, club, practice) on a
+// schedule. Blocks count for conflicts and free time but never for credits
+// or enrollment.
+type BusyBlock struct {
+	ID         int       `db:"id" json:"id"`
+	ScheduleID int       `db:"schedule_id" json:"schedule_id"`
+	Label      string    `db:"label" json:"label"`
+	Days       []string  `db:"days" json:"days"`
+	StartTime  string    `db:"start_time" json:"start_time"`
+	EndTime    string    `db:"end_time" json:"end_time"`
+	Location   *string   `db:"location" json:"location"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+type BusyBlockRequest struct {
+	Label     string   `json:"label" validate:"required,max=100"`
+	Days      []string `json:"days" validate:"required,min=1,unique,dive,oneof=Monday Tuesday Wednesday Thursday Friday Saturday Sunday"`
+	StartTime string   `json:"start_time" validate:"required"`
+	EndTime   string   `json:"end_time" validate:"required"`
+	Location  *string  `json:"location" validate:"omitempty,max=100"`
+}
+
+// CheckTimes verifies both times parse and the block ends after it starts
+func (r BusyBlockRequest) CheckTimes() error {
+	start, err := ParseClock(r.StartTime)
+	if err != nil {
+		return err
+	}
+	end, err := ParseClock(r.EndTime)
+	if err != nil {
+		return err
+	}
+	if end <= start {
+		return errors.New("end_time must be after start_time")
+	}
+	return nil
+}
+
+// Slots returns one time slot per day of the block. The block's location is
+// used as its building, so TightTransfers can place blocks held in a building
+// it knows.
+func (b BusyBlock) Slots() []TimeSlot {
+	start, err := ParseClock(b.StartTime)
+	if err != nil {
+		return nil
+	}
+	end, err := ParseClock(b.EndTime)
+	if err != nil {
+		return nil
+	}
+
+	var building *string
+	if b.Location != nil {
+		if location := strings.TrimSpace(*b.Location); location != "" {
+			building = &location
+		}
+	}
+
+	slots := make([]TimeSlot, 0, len(b.Days))
+	for _, day := range b.Days {
+		slots = append(slots, TimeSlot{DayOfWeek: day, Start: start, End: end, Label: b.Label, Building: building})
+	}
+	return slots
+}
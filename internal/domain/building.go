@@ -0,0 +1,162 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// walkingMetersPerMinute is used to estimate walking time from coordinates
+// when the matrix has no entry for a pair of buildings
+const walkingMetersPerMinute = 70
+
+type Building struct {
+	Code               string    `db:"code" json:"code"`
+	Name               *string   `db:"name" json:"name"`
+	Latitude           *float64  `db:"latitude" json:"latitude"`
+	Longitude          *float64  `db:"longitude" json:"longitude"`
+	WheelchairAccess   bool      `db:"wheelchair_access" json:"wheelchair_access"`
+	AccessibilityNotes *string   `db:"accessibility_notes" json:"accessibility_notes"`
+	CreatedAt          time.Time `db:"created_at" json:"created_at"`
+}
+
+type UpdateBuildingRequest struct {
+	Name               *string  `json:"name" validate:"omitempty,max=200"`
+	Latitude           *float64 `json:"latitude" validate:"omitempty,min=-90,max=90"`
+	Longitude          *float64 `json:"longitude" validate:"omitempty,min=-180,max=180"`
+	WheelchairAccess   bool     `json:"wheelchair_access"`
+	AccessibilityNotes *string  `json:"accessibility_notes"`
+}
+
+type WalkingTime struct {
+	FromBuilding string `db:"from_building" json:"from_building"`
+	ToBuilding   string `db:"to_building" json:"to_building"`
+	Minutes      int    `db:"minutes" json:"minutes"`
+}
+
+type SetWalkingTimeRequest struct {
+	FromBuilding string `json:"from_building" validate:"required"`
+	ToBuilding   string `json:"to_building" validate:"required,nefield=FromBuilding"`
+	Minutes      int    `json:"minutes" validate:"min=0,max=120"`
+}
+
+// WalkingMatrix answers walking-time queries between buildings. Configured
+// times win; otherwise the time is estimated from coordinates.
+type WalkingMatrix struct {
+	times     map[[2]string]int
+	buildings map[string]Building
+}
+
+func NewWalkingMatrix(buildings []Building, times []WalkingTime) WalkingMatrix {
+	m := WalkingMatrix{
+		times:     make(map[[2]string]int, len(times)*2),
+		buildings: make(map[string]Building, len(buildings)),
+	}
+	for _, b := range buildings {
+		m.buildings[b.Code] = b
+	}
+	for _, t := range times {
+		// Walking times are symmetric
+		m.times[[2]string{t.FromBuilding, t.ToBuilding}] = t.Minutes
+		m.times[[2]string{t.ToBuilding, t.FromBuilding}] = t.Minutes
+	}
+	return m
+}
+
+// Minutes returns the walking time between two buildings and whether it is known
+func (m WalkingMatrix) Minutes(from, to string) (int, bool) {
+	if from == to {
+		return 0, true
+	}
+	if minutes, ok := m.times[[2]string{from, to}]; ok {
+		return minutes, true
+	}
+
+	a, b := m.buildings[from], m.buildings[to]
+	if a.Latitude == nil || a.Longitude == nil || b.Latitude == nil || b.Longitude == nil {
+		return 0, false
+	}
+
+	meters := haversineMeters(*a.Latitude, *a.Longitude, *b.Latitude, *b.Longitude)
+	return int(math.Ceil(meters / walkingMetersPerMinute)), true
+}
+
+// knows reports whether code is a building of the matrix
+func (m WalkingMatrix) knows(code string) bool {
+	_, ok := m.buildings[code]
+	return ok
+}
+
+func haversineMeters(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadius = 6371000
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+type TransferWarning struct {
+	DayOfWeek      string `json:"day_of_week"`
+	From           string `json:"from"`
+	To             string `json:"to"`
+	FromBuilding   string `json:"from_building"`
+	ToBuilding     string `json:"to_building"`
+	BreakMinutes   int    `json:"break_minutes"`
+	WalkingMinutes int    `json:"walking_minutes"`
+}
+
+// TightTransfers flags consecutive slots in different buildings whose break
+// is shorter than the walk between them. Busy blocks are skipped unless their
+// location is a building in the matrix, so a short commitment between two
+// classes does not hide the walk from one to the other. Classes without a
+// building, and pairs with an unknown walking time, are not flagged.
+func TightTransfers(slots []TimeSlot, matrix WalkingMatrix) []TransferWarning {
+	located := make([]TimeSlot, 0, len(slots))
+	for _, slot := range slots {
+		if slot.SectionID == nil && (slot.Building == nil || !matrix.knows(*slot.Building)) {
+			continue
+		}
+		located = append(located, slot)
+	}
+	slots = located
+	SortSlots(slots)
+
+	warnings := []TransferWarning{}
+	for i := 1; i < len(slots); i++ {
+		prev, next := slots[i-1], slots[i]
+		if prev.DayOfWeek != next.DayOfWeek || next.Start < prev.End {
+			continue
+		}
+		if prev.Building == nil || next.Building == nil {
+			continue
+		}
+
+		walking, ok := matrix.Minutes(*prev.Building, *next.Building)
+		breakMinutes := next.Start - prev.End
+		if !ok || walking <= breakMinutes {
+			continue
+		}
+
+		warnings = append(warnings, TransferWarning{
+			DayOfWeek:      next.DayOfWeek,
+			From:           prev.Label,
+			To:             next.Label,
+			FromBuilding:   *prev.Building,
+			ToBuilding:     *next.Building,
+			BreakMinutes:   breakMinutes,
+			WalkingMinutes: walking,
+		})
+	}
+	return warnings
+}
+
+// ScheduleValidation collects everything wrong or risky about a schedule.
+// IsValid only reflects errors (conflicts, credit load); warnings are advisory.
+type ScheduleValidation struct {
+	IsValid          bool              `json:"is_valid"`
+	Conflicts        []Conflict        `json:"conflicts"`
+	Errors           []ValidationError `json:"errors"`
+	TransferWarnings []TransferWarning `json:"transfer_warnings"`
+}
@@ -0,0 +1,221 @@
+package domain
+
+import "testing"
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// class returns a class meeting slot; an empty building means none
+func class(day string, start, end int, label, building string) TimeSlot {
+	slot := TimeSlot{DayOfWeek: day, Start: start, End: end, Label: label, SectionID: ptr(1)}
+	if building != "" {
+		slot.Building = &building
+	}
+	return slot
+}
+
+func testMatrix() WalkingMatrix {
+	return NewWalkingMatrix(
+		[]Building{
+			{Code: "A", Latitude: ptr(51.09), Longitude: ptr(71.40)},
+			{Code: "B", Latitude: ptr(51.10), Longitude: ptr(71.40)},
+			{Code: "C"},
+			{Code: "D"},
+		},
+		[]WalkingTime{{FromBuilding: "A", ToBuilding: "C", Minutes: 5}},
+	)
+}
+
+func TestWalkingMatrixMinutes(t *testing.T) {
+	matrix := testMatrix()
+
+	tests := []struct {
+		name     string
+		from, to string
+		minutes  int
+		known    bool
+	}{
+		{"same building", "C", "C", 0, true},
+		{"configured", "A", "C", 5, true},
+		{"configured is symmetric", "C", "A", 5, true},
+		// 0.01 degrees of latitude is about 1112 m, or 15.9 minutes at 70 m/min
+		{"estimated from coordinates", "A", "B", 16, true},
+		{"estimated is symmetric", "B", "A", 16, true},
+		{"no coordinates", "C", "D", 0, false},
+		{"one side without coordinates", "B", "D", 0, false},
+		{"unknown building", "A", "Z", 0, false},
+	}
+
+	for _, tt := range tests {
+		minutes, known := matrix.Minutes(tt.from, tt.to)
+		if minutes != tt.minutes || known != tt.known {
+			t.Errorf("%s: Minutes(%s, %s) = %d, %v, want %d, %v", tt.name, tt.from, tt.to, minutes, known, tt.minutes, tt.known)
+		}
+	}
+}
+
+func TestWalkingMatrixPrefersConfiguredTime(t *testing.T) {
+	matrix := NewWalkingMatrix(
+		[]Building{
+			{Code: "A", Latitude: ptr(51.09), Longitude: ptr(71.40)},
+			{Code: "B", Latitude: ptr(51.10), Longitude: ptr(71.40)},
+		},
+		[]WalkingTime{{FromBuilding: "A", ToBuilding: "B", Minutes: 3}},
+	)
+
+	if minutes, _ := matrix.Minutes("A", "B"); minutes != 3 {
+		t.Errorf("Minutes(A, B) = %d, want the configured 3", minutes)
+	}
+}
+
+func TestHaversineMeters(t *testing.T) {
+	if d := haversineMeters(51.09, 71.40, 51.09, 71.40); d != 0 {
+		t.Errorf("distance to itself = %f, want 0", d)
+	}
+
+	// One degree of latitude is about 111.2 km anywhere
+	if d := haversineMeters(0, 0, 1, 0); d < 111000 || d > 111400 {
+		t.Errorf("one degree of latitude = %f m, want about 111195", d)
+	}
+}
+
+func TestTightTransfers(t *testing.T) {
+	matrix := testMatrix()
+
+	tests := []struct {
+		name  string
+		slots []TimeSlot
+		want  []TransferWarning
+	}{
+		{
+			name: "back to back in different buildings",
+			slots: []TimeSlot{
+				class("Monday", 540, 600, "CSCI 151", "A"),
+				class("Monday", 600, 660, "MATH 161", "C"),
+			},
+			want: []TransferWarning{{DayOfWeek: "Monday", From: "CSCI 151", To: "MATH 161", FromBuilding: "A", ToBuilding: "C", BreakMinutes: 0, WalkingMinutes: 5}},
+		},
+		{
+			name: "break shorter than the walk",
+			slots: []TimeSlot{
+				class("Monday", 540, 600, "CSCI 151", "A"),
+				class("Monday", 610, 660, "MATH 161", "B"),
+			},
+			want: []TransferWarning{{DayOfWeek: "Monday", From: "CSCI 151", To: "MATH 161", FromBuilding: "A", ToBuilding: "B", BreakMinutes: 10, WalkingMinutes: 16}},
+		},
+		{
+			name: "break exactly as long as the walk",
+			slots: []TimeSlot{
+				class("Monday", 540, 600, "CSCI 151", "A"),
+				class("Monday", 605, 660, "MATH 161", "C"),
+			},
+		},
+		{
+			name: "break longer than the walk",
+			slots: []TimeSlot{
+				class("Monday", 540, 600, "CSCI 151", "A"),
+				class("Monday", 630, 660, "MATH 161", "B"),
+			},
+		},
+		{
+			name: "same building",
+			slots: []TimeSlot{
+				class("Monday", 540, 600, "CSCI 151", "A"),
+				class("Monday", 600, 660, "MATH 161", "A"),
+			},
+		},
+		{
+			name: "different days",
+			slots: []TimeSlot{
+				class("Monday", 540, 600, "CSCI 151", "A"),
+				class("Tuesday", 600, 660, "MATH 161", "C"),
+			},
+		},
+		{
+			name: "overlapping classes",
+			slots: []TimeSlot{
+				class("Monday", 540, 620, "CSCI 151", "A"),
+				class("Monday", 600, 660, "MATH 161", "C"),
+			},
+		},
+		{
+			name: "class without a building",
+			slots: []TimeSlot{
+				class("Monday", 540, 600, "CSCI 151", "A"),
+				class("Monday", 600, 660, "MATH 161", ""),
+			},
+		},
+		{
+			name: "walking time unknown",
+			slots: []TimeSlot{
+				class("Monday", 540, 600, "CSCI 151", "C"),
+				class("Monday", 600, 660, "MATH 161", "D"),
+			},
+		},
+		{
+			name: "building missing from the matrix",
+			slots: []TimeSlot{
+				class("Monday", 540, 600, "CSCI 151", "A"),
+				class("Monday", 600, 660, "MATH 161", "Z"),
+			},
+		},
+		{
+			name: "unsorted input",
+			slots: []TimeSlot{
+				class("Monday", 600, 660, "MATH 161", "C"),
+				class("Monday", 540, 600, "CSCI 151", "A"),
+			},
+			want: []TransferWarning{{DayOfWeek: "Monday", From: "CSCI 151", To: "MATH 161", FromBuilding: "A", ToBuilding: "C", BreakMinutes: 0, WalkingMinutes: 5}},
+		},
+		{
+			name: "block without a known building is skipped",
+			slots: []TimeSlot{
+				class("Monday", 540, 600, "CSCI 151", "A"),
+				{DayOfWeek: "Monday", Start: 600, End: 601, Label: "Call", Building: ptr("Cafe")},
+				class("Monday", 602, 660, "MATH 161", "C"),
+			},
+			want: []TransferWarning{{DayOfWeek: "Monday", From: "CSCI 151", To: "MATH 161", FromBuilding: "A", ToBuilding: "C", BreakMinutes: 2, WalkingMinutes: 5}},
+		},
+		{
+			name: "block in a known building",
+			slots: []TimeSlot{
+				class("Monday", 540, 600, "CSCI 151", "A"),
+				{DayOfWeek: "Monday", Start: 600, End: 630, Label: "Club", Building: ptr("C")},
+			},
+			want: []TransferWarning{{DayOfWeek: "Monday", From: "CSCI 151", To: "Club", FromBuilding: "A", ToBuilding: "C", BreakMinutes: 0, WalkingMinutes: 5}},
+		},
+	}
+
+	for _, tt := range tests {
+		got := TightTransfers(tt.slots, matrix)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %d warnings %+v, want %d", tt.name, len(got), got, len(tt.want))
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: warning %d = %+v, want %+v", tt.name, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestBusyBlockSlotsBuilding(t *testing.T) {
+	block := BusyBlock{Label: "Club", Days: []string{"Monday", "Wednesday"}, StartTime: "18:00", EndTime: "19:00", Location: ptr(" C3 ")}
+
+	slots := block.Slots()
+	if len(slots) != 2 {
+		t.Fatalf("got %d slots, want 2", len(slots))
+	}
+	for _, slot := range slots {
+		if slot.Building == nil || *slot.Building != "C3" {
+			t.Errorf("%s slot building = %v, want C3", slot.DayOfWeek, slot.Building)
+		}
+	}
+
+	block.Location = ptr("  ")
+	if slots := block.Slots(); slots[0].Building != nil {
+		t.Errorf("blank location gave building %q", *slots[0].Building)
+	}
+}
@@ -0,0 +1,87 @@
+package domain
+
+type SectionRef struct {
+	SectionID     int    `json:"section_id"`
+	CourseCode    string `json:"course_code"`
+	SectionNumber string `json:"section_number"`
+	SectionType   string `json:"section_type"`
+}
+
+type ScheduleSummary struct {
+	ScheduleID     int          `json:"schedule_id"`
+	ScheduleName   string       `json:"schedule_name"`
+	TotalCredits   int          `json:"total_credits"`
+	UniqueSections []SectionRef `json:"unique_sections"`
+	Conflicts      []Conflict   `json:"conflicts"`
+}
+
+type ScheduleComparison struct {
+	Schedules      []ScheduleSummary `json:"schedules"`
+	SharedSections []SectionRef      `json:"shared_sections"`
+}
+
+type CloneScheduleRequest struct {
+	ScheduleName *string `json:"schedule_name" validate:"omitempty,min=1,max=100"`
+}
+
+// sectionRefs returns the distinct sections on a schedule, in schedule order
+func sectionRefs(schedule ScheduleWithSections) []SectionRef {
+	seen := make(map[int]bool)
+	var refs []SectionRef
+	for _, s := range schedule.Sections {
+		if seen[s.ID] {
+			continue
+		}
+		seen[s.ID] = true
+		refs = append(refs, SectionRef{
+			SectionID:     s.ID,
+			CourseCode:    s.Course.CourseCode,
+			SectionNumber: s.SectionNumber,
+			SectionType:   s.SectionType,
+		})
+	}
+	return refs
+}
+
+// CompareSchedules diffs schedules by section: sections found in every
+// schedule are shared, sections found in exactly one are unique to it
+func CompareSchedules(schedules []ScheduleWithSections) ScheduleComparison {
+	refsBySchedule := make([][]SectionRef, len(schedules))
+	occurrences := make(map[int]int)
+	for i, schedule := range schedules {
+		refsBySchedule[i] = sectionRefs(schedule)
+		for _, ref := range refsBySchedule[i] {
+			occurrences[ref.SectionID]++
+		}
+	}
+
+	comparison := ScheduleComparison{
+		Schedules:      make([]ScheduleSummary, 0, len(schedules)),
+		SharedSections: []SectionRef{},
+	}
+
+	for i, schedule := range schedules {
+		summary := ScheduleSummary{
+			ScheduleID:     schedule.ID,
+			ScheduleName:   schedule.ScheduleName,
+			TotalCredits:   schedule.TotalCredits,
+			UniqueSections: []SectionRef{},
+			Conflicts:      FindConflicts(schedule.Slots()),
+		}
+
+		for _, ref := range refsBySchedule[i] {
+			switch occurrences[ref.SectionID] {
+			case 1:
+				summary.UniqueSections = append(summary.UniqueSections, ref)
+			case len(schedules):
+				if i == 0 {
+					comparison.SharedSections = append(comparison.SharedSections, ref)
+				}
+			}
+		}
+
+		comparison.Schedules = append(comparison.Schedules, summary)
+	}
+
+	return comparison
+}
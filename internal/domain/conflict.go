@@ -0,0 +1,57 @@
+package domain
+
+type Conflict struct {
+	DayOfWeek       string `json:"day_of_week"`
+	StartTime       string `json:"start_time"`
+	EndTime         string `json:"end_time"`
+	First           string `json:"first"`
+	Second          string `json:"second"`
+	FirstSectionID  *int   `json:"first_section_id"`
+	SecondSectionID *int   `json:"second_section_id"`
+}
+
+// FindConflicts returns every pair of overlapping slots, with the overlapping
+// window. Slots of the same section never conflict with each other.
+func FindConflicts(slots []TimeSlot) []Conflict {
+	conflicts := []Conflict{}
+	for i := 0; i < len(slots); i++ {
+		for j := i + 1; j < len(slots); j++ {
+			if conflict, ok := findConflict(slots[i], slots[j]); ok {
+				conflicts = append(conflicts, conflict)
+			}
+		}
+	}
+	return conflicts
+}
+
+// FindConflictsWith returns the overlaps between candidate slots and existing
+// ones, ignoring overlaps within either set
+func FindConflictsWith(existing, candidate []TimeSlot) []Conflict {
+	conflicts := []Conflict{}
+	for _, a := range existing {
+		for _, b := range candidate {
+			if conflict, ok := findConflict(a, b); ok {
+				conflicts = append(conflicts, conflict)
+			}
+		}
+	}
+	return conflicts
+}
+
+func findConflict(a, b TimeSlot) (Conflict, bool) {
+	if a.SectionID != nil && b.SectionID != nil && *a.SectionID == *b.SectionID {
+		return Conflict{}, false
+	}
+	if !a.Overlaps(b) {
+		return Conflict{}, false
+	}
+	return Conflict{
+		DayOfWeek:       a.DayOfWeek,
+		StartTime:       FormatClock(max(a.Start, b.Start)),
+		EndTime:         FormatClock(min(a.End, b.End)),
+		First:           a.Label,
+		Second:          b.Label,
+		FirstSectionID:  a.SectionID,
+		SecondSectionID: b.SectionID,
+	}, true
+}
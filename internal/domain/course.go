@@ -32,6 +32,7 @@ type SectionMeeting struct {
 	EndTime   string  `db:"end_time" json:"end_time"`
 	Room      *string `db:"room" json:"room"`
 	Building  *string `db:"building" json:"building"`
+	RoomID    *int    `db:"room_id" json:"room_id"`
 }
 
 // Composite types for API responses
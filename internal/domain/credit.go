@@ -0,0 +1,42 @@
+package domain
+
+import "fmt"
+
+type CreditLimit struct {
+	ID          int    `db:"id" json:"id"`
+	YearOfStudy int    `db:"year_of_study" json:"year_of_study"`
+	Standing    string `db:"standing" json:"standing"`
+	MinCredits  int    `db:"min_credits" json:"min_credits"`
+	MaxCredits  int    `db:"max_credits" json:"max_credits"`
+}
+
+// DefaultCreditLimit is used when no row in credit_limits matches the student
+var DefaultCreditLimit = CreditLimit{MinCredits: 12, MaxCredits: 36}
+
+// Check validates a credit total against the limit. approvedCredits is the
+// credit count granted by an approved overload petition (0 if none); the
+// minimum is only enforced on submission.
+func (l CreditLimit) Check(totalCredits, approvedCredits int, submitting bool) []ValidationError {
+	var errs []ValidationError
+
+	maxCredits := l.MaxCredits
+	if approvedCredits > maxCredits {
+		maxCredits = approvedCredits
+	}
+
+	if totalCredits > maxCredits {
+		errs = append(errs, ValidationError{
+			Field:   "total_credits",
+			Message: fmt.Sprintf("%d credits exceeds the maximum of %d; submit an overload petition", totalCredits, maxCredits),
+		})
+	}
+
+	if submitting && totalCredits < l.MinCredits {
+		errs = append(errs, ValidationError{
+			Field:   "total_credits",
+			Message: fmt.Sprintf("%d credits is below the minimum of %d", totalCredits, l.MinCredits),
+		})
+	}
+
+	return errs
+}
@@ -0,0 +1,47 @@
+package domain
+
+import "time"
+
+// AccountExport is everything stored about a student, as returned by the
+// data export. All rows are read from one snapshot.
+//
+// Left out are secrets and the records that only exist to check them:
+// the password hash, the TOTP secret, recovery codes, refresh and account
+// token hashes, pending login challenges and login throttles. Catalog data
+// (courses, sections, meetings) is referenced by id and course code only.
+type AccountExport struct {
+	ExportedAt       time.Time                 `json:"exported_at"`
+	Student          Student                   `json:"student"`
+	Schedules        []Schedule                `json:"schedules"`
+	ScheduleSections []ExportedScheduleSection `json:"schedule_sections"`
+	BusyBlocks       []BusyBlock               `json:"busy_blocks"`
+	Reviews          []ScheduleReview          `json:"reviews"`
+	Shares           []ScheduleShare           `json:"shares"`
+	Watchlist        []WatchlistItem           `json:"watchlist"`
+	Notifications    []Notification            `json:"notifications"`
+	Petitions        []OverloadPetition        `json:"petitions"`
+	Groups           []ExportedGroupMembership `json:"groups"`
+	Sessions         []Session                 `json:"sessions"`
+	Identities       []LinkedIdentity          `json:"identities"`
+	AuditLog         []AuditEntry              `json:"audit_log"`
+}
+
+// ExportedScheduleSection is a section placed in one of the student's
+// schedules
+type ExportedScheduleSection struct {
+	ScheduleSection
+	CourseCode    string `json:"course_code"`
+	SectionNumber string `json:"section_number"`
+}
+
+// ExportedGroupMembership is a study group the student has joined or been
+// invited to
+type ExportedGroupMembership struct {
+	GroupID    int        `json:"group_id"`
+	GroupName  string     `json:"group_name"`
+	OwnerID    int        `json:"owner_id"`
+	ScheduleID *int       `json:"schedule_id"`
+	Status     string     `json:"status"`
+	InvitedAt  time.Time  `json:"invited_at"`
+	JoinedAt   *time.Time `json:"joined_at"`
+}
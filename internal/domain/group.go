@@ -0,0 +1,95 @@
+package domain
+
+import "time"
+
+const (
+	MemberInvited = "invited"
+	MemberJoined  = "joined"
+)
+
+type StudyGroup struct {
+	ID        int       `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	OwnerID   int       `db:"owner_id" json:"owner_id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type GroupMember struct {
+	GroupID     int        `db:"group_id" json:"group_id"`
+	StudentID   int        `db:"student_id" json:"student_id"`
+	StudentName string     `json:"student_name"`
+	ScheduleID  *int       `db:"schedule_id" json:"schedule_id"`
+	Status      string     `db:"status" json:"status"`
+	InvitedAt   time.Time  `db:"invited_at" json:"invited_at"`
+	JoinedAt    *time.Time `db:"joined_at" json:"joined_at"`
+}
+
+type GroupWithMembers struct {
+	StudyGroup
+	Members []GroupMember `json:"members"`
+}
+
+type CreateGroupRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+type InviteMemberRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type SetGroupScheduleRequest struct {
+	ScheduleID int `json:"schedule_id" validate:"required"`
+}
+
+type FreeSlot struct {
+	DayOfWeek string `json:"day_of_week"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	Minutes   int    `json:"minutes"`
+}
+
+type GroupFreeTime struct {
+	// MembersIncluded counts joined members who have picked a schedule;
+	// members without one are ignored
+	MembersIncluded int        `json:"members_included"`
+	FreeSlots       []FreeSlot `json:"free_slots"`
+}
+
+// FreeTime returns, for each day, the gaps of at least minMinutes within
+// [windowStart, windowEnd) not covered by any busy slot. Times are minutes
+// since midnight.
+func FreeTime(busy []TimeSlot, days []string, windowStart, windowEnd, minMinutes int) []FreeSlot {
+	busy = append([]TimeSlot(nil), busy...)
+	SortSlots(busy)
+
+	free := []FreeSlot{}
+	for _, day := range days {
+		cursor := windowStart
+		addGap := func(end int) {
+			if end-cursor >= minMinutes {
+				free = append(free, FreeSlot{
+					DayOfWeek: day,
+					StartTime: FormatClock(cursor),
+					EndTime:   FormatClock(end),
+					Minutes:   end - cursor,
+				})
+			}
+		}
+
+		for _, slot := range busy {
+			if slot.DayOfWeek != day || slot.End <= cursor || slot.Start >= windowEnd {
+				continue
+			}
+			if slot.Start > cursor {
+				addGap(slot.Start)
+			}
+			cursor = max(cursor, slot.End)
+		}
+
+		if cursor < windowEnd {
+			addGap(windowEnd)
+		}
+	}
+
+	return free
+}
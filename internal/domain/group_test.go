@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFreeTime(t *testing.T) {
+	weekdays := []string{"Monday", "Tuesday"}
+	free := func(day string, start, end int) FreeSlot {
+		return FreeSlot{DayOfWeek: day, StartTime: FormatClock(start), EndTime: FormatClock(end), Minutes: end - start}
+	}
+
+	tests := []struct {
+		name string
+		busy []TimeSlot
+		days []string
+		min  int
+		want []FreeSlot
+	}{
+		{
+			name: "nothing busy",
+			days: weekdays,
+			min:  30,
+			want: []FreeSlot{free("Monday", 480, 1080), free("Tuesday", 480, 1080)},
+		},
+		{
+			name: "no days",
+			busy: []TimeSlot{slot("Monday", 600, 660, "")},
+			min:  30,
+			want: []FreeSlot{},
+		},
+		{
+			name: "one class",
+			busy: []TimeSlot{slot("Monday", 600, 660, "")},
+			days: []string{"Monday"},
+			min:  30,
+			want: []FreeSlot{free("Monday", 480, 600), free("Monday", 660, 1080)},
+		},
+		{
+			name: "overlapping and nested slots merge",
+			busy: []TimeSlot{
+				slot("Monday", 600, 700, ""),
+				slot("Monday", 650, 720, ""),
+				slot("Monday", 610, 620, ""),
+			},
+			days: []string{"Monday"},
+			min:  30,
+			want: []FreeSlot{free("Monday", 480, 600), free("Monday", 720, 1080)},
+		},
+		{
+			name: "adjacent slots leave no gap",
+			busy: []TimeSlot{
+				slot("Monday", 660, 720, ""),
+				slot("Monday", 600, 660, ""),
+			},
+			days: []string{"Monday"},
+			min:  1,
+			want: []FreeSlot{free("Monday", 480, 600), free("Monday", 720, 1080)},
+		},
+		{
+			name: "gaps shorter than the minimum are dropped",
+			busy: []TimeSlot{
+				slot("Monday", 500, 600, ""),
+				slot("Monday", 620, 1060, ""),
+			},
+			days: []string{"Monday"},
+			min:  30,
+			want: []FreeSlot{},
+		},
+		{
+			name: "gap exactly the minimum is kept",
+			busy: []TimeSlot{slot("Monday", 510, 1080, "")},
+			days: []string{"Monday"},
+			min:  30,
+			want: []FreeSlot{free("Monday", 480, 510)},
+		},
+		{
+			name: "slots crossing the window edges are clipped",
+			busy: []TimeSlot{
+				slot("Monday", 420, 540, ""),
+				slot("Monday", 1020, 1200, ""),
+			},
+			days: []string{"Monday"},
+			min:  30,
+			want: []FreeSlot{free("Monday", 540, 1020)},
+		},
+		{
+			name: "slots outside the window are ignored",
+			busy: []TimeSlot{
+				slot("Monday", 360, 480, ""),
+				slot("Monday", 1080, 1140, ""),
+			},
+			days: []string{"Monday"},
+			min:  30,
+			want: []FreeSlot{free("Monday", 480, 1080)},
+		},
+		{
+			name: "slots only count on their own day",
+			busy: []TimeSlot{slot("Tuesday", 480, 1080, "")},
+			days: weekdays,
+			min:  30,
+			want: []FreeSlot{free("Monday", 480, 1080)},
+		},
+		{
+			name: "whole day busy",
+			busy: []TimeSlot{slot("Monday", 0, 1440, "")},
+			days: []string{"Monday"},
+			min:  1,
+			want: []FreeSlot{},
+		},
+	}
+
+	for _, tt := range tests {
+		got := FreeTime(tt.busy, tt.days, 480, 1080, tt.min)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: FreeTime =\n%+v\nwant\n%+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFreeTimeWholeDay(t *testing.T) {
+	busy := []TimeSlot{slot("Friday", 0, 60, ""), slot("Friday", 1380, 1440, "")}
+
+	got := FreeTime(busy, []string{"Friday"}, 0, 1440, 30)
+	want := []FreeSlot{{DayOfWeek: "Friday", StartTime: "01:00:00", EndTime: "23:00:00", Minutes: 1320}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FreeTime = %+v, want %+v", got, want)
+	}
+}
+
+func TestFreeTimeDoesNotReorderInput(t *testing.T) {
+	busy := []TimeSlot{slot("Monday", 700, 760, "b"), slot("Monday", 600, 660, "a")}
+
+	FreeTime(busy, []string{"Monday"}, 480, 1080, 30)
+	if busy[0].Label != "b" {
+		t.Error("FreeTime sorted the caller's slice")
+	}
+}
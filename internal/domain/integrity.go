@@ -0,0 +1,56 @@
+package domain
+
+import "time"
+
+// Catalog integrity issue kinds
+const (
+	IssueRoomDoubleBooked      = "room_double_booked"
+	IssueProfessorDoubleBooked = "professor_double_booked"
+	IssueOverCapacity          = "over_capacity"
+	IssueMissingRoom           = "missing_room"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// IntegrityIssue is one problem found in the imported catalog. SectionIDs and
+// MeetingIDs list every row involved so the registrar can fix the source data.
+type IntegrityIssue struct {
+	Kind       string `json:"kind"`
+	Severity   string `json:"severity"`
+	Message    string `json:"message"`
+	SectionIDs []int  `json:"section_ids"`
+	MeetingIDs []int  `json:"meeting_ids"`
+}
+
+type IntegrityReport struct {
+	GeneratedAt time.Time        `json:"generated_at"`
+	Errors      int              `json:"errors"`
+	Warnings    int              `json:"warnings"`
+	Counts      map[string]int   `json:"counts"`
+	Issues      []IntegrityIssue `json:"issues"`
+}
+
+// NewIntegrityReport totals issues by kind and by severity
+func NewIntegrityReport(issues []IntegrityIssue) *IntegrityReport {
+	report := &IntegrityReport{
+		GeneratedAt: time.Now(),
+		Counts:      map[string]int{},
+		Issues:      issues,
+	}
+	if report.Issues == nil {
+		report.Issues = []IntegrityIssue{}
+	}
+
+	for _, issue := range report.Issues {
+		report.Counts[issue.Kind]++
+		if issue.Severity == SeverityError {
+			report.Errors++
+		} else {
+			report.Warnings++
+		}
+	}
+	return report
+}
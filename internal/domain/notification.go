@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// Notification kinds
+const (
+	NotificationSeatAvailable = "seat_available"
+)
+
+// Notification is an entry in a student's in-app feed
+type Notification struct {
+	ID        int        `db:"id" json:"id"`
+	StudentID int        `db:"student_id" json:"student_id"`
+	Kind      string     `db:"kind" json:"kind"`
+	SectionID *int       `db:"section_id" json:"section_id"`
+	Title     string     `db:"title" json:"title"`
+	Body      string     `db:"body" json:"body"`
+	ReadAt    *time.Time `db:"read_at" json:"read_at"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+}
+
+// NotificationDelivery is a new notification with the contact details needed
+// to send it outside the app
+type NotificationDelivery struct {
+	Notification
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// ExternalIdentity is what an OpenID Connect provider asserted about a user
+// in their ID token
+type ExternalIdentity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+	StudentID     string // empty if the provider has no student ID claim
+}
+
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
+
+// LinkedIdentity is an identity provider account linked to a student
+type LinkedIdentity struct {
+	Issuer      string    `db:"issuer" json:"issuer"`
+	Subject     string    `db:"subject" json:"subject"`
+	Email       *string   `db:"email" json:"email"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	LastLoginAt time.Time `db:"last_login_at" json:"last_login_at"`
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "encoding/json"
+
+// OptionalString is a nullable request field that tells an omitted value
+// apart from an explicit null: Set is false when the field was left out, and
+// Value is nil when it was sent as null
+type OptionalString struct {
+	Set   bool
+	Value *string
+}
+
+func (o *OptionalString) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Value = nil
+		return nil
+	}
+	return json.Unmarshal(data, &o.Value)
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestUpdateScheduleRequestDescription(t *testing.T) {
+	tests := []struct {
+		body    string
+		set     bool
+		cleared bool
+		value   string
+	}{
+		{`{"schedule_name": "Fall"}`, false, false, ""},
+		{`{"description": null}`, true, true, ""},
+		{`{"description": "with labs"}`, true, false, "with labs"},
+		{`{"description": ""}`, true, false, ""},
+	}
+
+	for _, tt := range tests {
+		var req UpdateScheduleRequest
+		if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+			t.Fatalf("%s: %v", tt.body, err)
+		}
+
+		d := req.Description
+		if d.Set != tt.set || (d.Value == nil) != (tt.cleared || !tt.set) {
+			t.Errorf("%s: set = %v, value = %v", tt.body, d.Set, d.Value)
+			continue
+		}
+		if d.Value != nil && *d.Value != tt.value {
+			t.Errorf("%s: value = %q, want %q", tt.body, *d.Value, tt.value)
+		}
+	}
+}
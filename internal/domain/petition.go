@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+const (
+	PetitionPending  = "pending"
+	PetitionApproved = "approved"
+	PetitionDenied   = "denied"
+)
+
+type OverloadPetition struct {
+	ID               int        `db:"id" json:"id"`
+	StudentID        int        `db:"student_id" json:"student_id"`
+	ScheduleID       int        `db:"schedule_id" json:"schedule_id"`
+	RequestedCredits int        `db:"requested_credits" json:"requested_credits"`
+	Reason           string     `db:"reason" json:"reason"`
+	Status           string     `db:"status" json:"status"`
+	ReviewerID       *int       `db:"reviewer_id" json:"reviewer_id"`
+	ReviewerComment  *string    `db:"reviewer_comment" json:"reviewer_comment"`
+	CreatedAt        time.Time  `db:"created_at" json:"created_at"`
+	ReviewedAt       *time.Time `db:"reviewed_at" json:"reviewed_at"`
+}
+
+type CreatePetitionRequest struct {
+	RequestedCredits int    `json:"requested_credits" validate:"required,min=1"`
+	Reason           string `json:"reason" validate:"required"`
+}
+
+type ReviewPetitionRequest struct {
+	Status  string  `json:"status" validate:"required,oneof=approved denied"`
+	Comment *string `json:"comment"`
+}
@@ -0,0 +1,33 @@
+package domain
+
+import "time"
+
+type Room struct {
+	ID           int       `db:"id" json:"id"`
+	BuildingCode string    `db:"building_code" json:"building_code"`
+	RoomNumber   string    `db:"room_number" json:"room_number"`
+	Capacity     *int      `db:"capacity" json:"capacity"`
+	Features     []string  `db:"features" json:"features"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+}
+
+type UpdateRoomRequest struct {
+	Capacity *int     `json:"capacity" validate:"omitempty,min=1"`
+	Features []string `json:"features" validate:"omitempty,dive,required,max=50"`
+}
+
+// RoomBooking is one weekly meeting held in a room
+type RoomBooking struct {
+	MeetingID     int    `json:"meeting_id"`
+	SectionID     int    `json:"section_id"`
+	CourseCode    string `json:"course_code"`
+	SectionNumber string `json:"section_number"`
+	DayOfWeek     string `json:"day_of_week"`
+	StartTime     string `json:"start_time"`
+	EndTime       string `json:"end_time"`
+}
+
+type RoomSchedule struct {
+	Room
+	Bookings []RoomBooking `json:"bookings"`
+}
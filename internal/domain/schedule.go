@@ -1,6 +1,41 @@
 package domain
 
-import "time"
+import (
+	"slices"
+	"time"
+)
+
+const (
+	ScheduleDraft            = "draft"
+	ScheduleSubmitted        = "submitted"
+	ScheduleApproved         = "approved"
+	ScheduleChangesRequested = "changes_requested"
+	ScheduleEnrolled         = "enrolled"
+)
+
+// scheduleTransitions lists the statuses each status may move to
+var scheduleTransitions = map[string][]string{
+	ScheduleDraft:            {ScheduleSubmitted},
+	ScheduleSubmitted:        {ScheduleApproved, ScheduleChangesRequested, ScheduleDraft},
+	ScheduleChangesRequested: {ScheduleSubmitted},
+	ScheduleApproved:         {ScheduleEnrolled, ScheduleDraft},
+}
+
+// CanTransition reports whether a schedule may move from one status to another
+func CanTransition(from, to string) bool {
+	return slices.Contains(scheduleTransitions[from], to)
+}
+
+// StatusesTransitioningTo returns every status that may move to the given one
+func StatusesTransitioningTo(to string) []string {
+	var from []string
+	for status, targets := range scheduleTransitions {
+		if slices.Contains(targets, to) {
+			from = append(from, status)
+		}
+	}
+	return from
+}
 
 type Schedule struct {
 	ID           int       `db:"id" json:"id"`
@@ -8,9 +43,16 @@ type Schedule struct {
 	ScheduleName string    `db:"schedule_name" json:"schedule_name"`
 	Description  *string   `db:"description" json:"description"`
 	IsSubmitted  bool      `db:"is_submitted" json:"is_submitted"`
+	Status       string    `db:"status" json:"status"`
 	CreatedAt    time.Time `db:"created_at" json:"created_at"`
 }
 
+// IsEditable reports whether sections may be added or removed. Submitted
+// schedules are locked until an advisor requests changes.
+func (s Schedule) IsEditable() bool {
+	return s.Status == ScheduleDraft || s.Status == ScheduleChangesRequested
+}
+
 type ScheduleSection struct {
 	ID         int       `db:"id" json:"id"`
 	ScheduleID int       `db:"schedule_id" json:"schedule_id"`
@@ -22,6 +64,7 @@ type ScheduleSection struct {
 type ScheduleWithSections struct {
 	Schedule
 	Sections     []SectionWithDetails `json:"sections"`
+	Blocks       []BusyBlock          `json:"blocks"`
 	TotalCredits int                  `json:"total_credits"`
 }
 
@@ -30,6 +73,12 @@ type CreateScheduleRequest struct {
 	Description  *string `json:"description"`
 }
 
+type UpdateScheduleRequest struct {
+	ScheduleName *string `json:"schedule_name" validate:"omitempty,min=1,max=100"`
+	// Sending null clears the description
+	Description OptionalString `json:"description" swaggertype:"string"`
+}
+
 type AddSectionRequest struct {
 	SectionID int  `json:"section_id" validate:"required"`
 	MeetingID *int `json:"meeting_id"`
@@ -44,3 +93,23 @@ type ValidationResult struct {
 	IsValid bool              `json:"is_valid"`
 	Errors  []ValidationError `json:"errors"`
 }
+
+type ScheduleReview struct {
+	ID         int       `db:"id" json:"id"`
+	ScheduleID int       `db:"schedule_id" json:"schedule_id"`
+	AdvisorID  *int      `db:"advisor_id" json:"advisor_id"`
+	Decision   string    `db:"decision" json:"decision"`
+	Comment    *string   `db:"comment" json:"comment"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+type ReviewScheduleRequest struct {
+	Comment *string `json:"comment"`
+}
+
+// AdviseeSchedule is a schedule listed in an advisor's inbox
+type AdviseeSchedule struct {
+	Schedule
+	StudentName   string `json:"student_name"`
+	StudentNumber string `json:"student_number"`
+}
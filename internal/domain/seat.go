@@ -0,0 +1,8 @@
+package domain
+
+// SeatUpdate is the current seat count of a section, as streamed to clients
+type SeatUpdate struct {
+	SectionID      int `json:"section_id"`
+	AvailableSeats int `json:"available_seats"`
+	TotalSeats     int `json:"total_seats"`
+}
@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+// Session is one login. Access tokens name it in their sid claim and its
+// refresh tokens rotate within it, so revoking it ends the whole login.
+// LastUsedAt moves forward each time a refresh token is rotated.
+// ImpersonatorID is set when an admin started the session to act as the
+// student.
+type Session struct {
+	ID             int        `db:"id" json:"id"`
+	StudentID      int        `db:"student_id" json:"student_id"`
+	UserAgent      *string    `db:"user_agent" json:"user_agent"`
+	IPAddress      *string    `db:"ip_address" json:"ip_address"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	LastUsedAt     time.Time  `db:"last_used_at" json:"last_used_at"`
+	ExpiresAt      time.Time  `db:"expires_at" json:"expires_at"`
+	RevokedAt      *time.Time `db:"revoked_at" json:"revoked_at"`
+	ImpersonatorID *int       `db:"impersonator_id" json:"impersonator_id"`
+	Current        bool       `db:"-" json:"current"` // the session making the request
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type ImpersonateRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// ImpersonationResponse carries a token acting as Student. There is no
+// refresh token; a new impersonation must be started once it expires.
+type ImpersonationResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Student   Student   `json:"student"`
+}
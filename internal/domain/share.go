@@ -0,0 +1,34 @@
+package domain
+
+import "time"
+
+type ScheduleShare struct {
+	ID          int        `db:"id" json:"id"`
+	ScheduleID  int        `db:"schedule_id" json:"schedule_id"`
+	Token       string     `db:"token" json:"token"`
+	DisplayName string     `db:"display_name" json:"display_name"`
+	ExpiresAt   *time.Time `db:"expires_at" json:"expires_at"`
+	RevokedAt   *time.Time `db:"revoked_at" json:"revoked_at"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+}
+
+// IsActive reports whether the link can still be opened
+func (s ScheduleShare) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && (s.ExpiresAt == nil || now.Before(*s.ExpiresAt))
+}
+
+type CreateShareRequest struct {
+	DisplayName    string `json:"display_name" validate:"required,max=100"`
+	ExpiresInHours *int   `json:"expires_in_hours" validate:"omitempty,min=1,max=8760"`
+}
+
+// SharedSchedule is the public view behind a share link. It deliberately
+// carries no student fields beyond the owner-chosen display name.
+type SharedSchedule struct {
+	DisplayName  string               `json:"display_name"`
+	ScheduleName string               `json:"schedule_name"`
+	Description  *string              `json:"description"`
+	TotalCredits int                  `json:"total_credits"`
+	Sections     []SectionWithDetails `json:"sections"`
+	ExpiresAt    *time.Time           `json:"expires_at"`
+}
@@ -2,16 +2,36 @@ package domain
 
 import "time"
 
+const (
+	RoleStudent   = "student"
+	RoleAdvisor   = "advisor"
+	RoleProfessor = "professor"
+	RoleAdmin     = "admin"
+)
+
+var Roles = []string{RoleStudent, RoleAdvisor, RoleProfessor, RoleAdmin}
+
+const (
+	StandingGood      = "good"
+	StandingProbation = "probation"
+)
+
 type Student struct {
-	ID                 int       `db:"id" json:"id"`
-	Email              string    `db:"email" json:"email"`
-	PasswordHash       string    `db:"password_hash" json:"-"`
-	FirstName          string    `db:"first_name" json:"first_name"`
-	LastName           string    `db:"last_name" json:"last_name"`
-	StudentID          string    `db:"student_id" json:"student_id"`
-	YearOfStudy        int       `db:"year_of_study" json:"year_of_study"`
-	TotalCreditsEarned int       `db:"total_credits_earned" json:"total_credits_earned"`
-	CreatedAt          time.Time `db:"created_at" json:"created_at"`
+	ID                 int        `db:"id" json:"id"`
+	Email              string     `db:"email" json:"email"`
+	PasswordHash       string     `db:"password_hash" json:"-"`
+	FirstName          string     `db:"first_name" json:"first_name"`
+	LastName           string     `db:"last_name" json:"last_name"`
+	StudentID          string     `db:"student_id" json:"student_id"`
+	YearOfStudy        int        `db:"year_of_study" json:"year_of_study"`
+	TotalCreditsEarned int        `db:"total_credits_earned" json:"total_credits_earned"`
+	Role               string     `db:"role" json:"role"`
+	Standing           string     `db:"standing" json:"standing"`
+	AdvisorID          *int       `db:"advisor_id" json:"advisor_id"`
+	EmailVerifiedAt    *time.Time `db:"email_verified_at" json:"email_verified_at"`
+	TwoFactorEnabled   bool       `db:"-" json:"two_factor_enabled"`
+	TwoFactorRequired  bool       `db:"two_factor_required" json:"two_factor_required"`
+	CreatedAt          time.Time  `db:"created_at" json:"created_at"`
 }
 
 type RegisterRequest struct {
@@ -29,6 +49,54 @@ type LoginRequest struct {
 }
 
 type AuthResponse struct {
-	Token   string  `json:"token"`
-	Student Student `json:"student"`
+	Token        string    `json:"token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	RefreshToken string    `json:"refresh_token"`
+	Student      Student   `json:"student"`
+	// Set only when the login also finished two-factor enrollment
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+// UpdateProfileRequest changes the student's own details; omitted fields are
+// left unchanged
+type UpdateProfileRequest struct {
+	FirstName   *string `json:"first_name" validate:"omitempty,min=1,max=100"`
+	LastName    *string `json:"last_name" validate:"omitempty,min=1,max=100"`
+	YearOfStudy *int    `json:"year_of_study" validate:"omitempty,min=1,max=5"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type UpdateRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=student advisor professor admin"`
+}
+
+type AssignAdvisorRequest struct {
+	AdvisorID *int `json:"advisor_id"`
+}
+
+// Account token purposes
+const (
+	TokenVerifyEmail   = "verify_email"
+	TokenResetPassword = "reset_password"
+)
+
+type TokenRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type PasswordResetRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ConfirmPasswordResetRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=8"`
 }
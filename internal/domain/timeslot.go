@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Days lists the days of the week in schedule order
+var Days = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+
+// TimeSlot is a weekly recurring interval. Start and End are minutes since
+// midnight.
+type TimeSlot struct {
+	DayOfWeek string
+	Start     int
+	End       int
+	Label     string
+	SectionID *int
+	Building  *string
+}
+
+// ParseClock converts a "15:04:05" or "15:04" time of day to minutes since midnight
+func ParseClock(value string) (int, error) {
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Hour()*60 + t.Minute(), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid time of day %q", value)
+}
+
+// FormatClock converts minutes since midnight to "15:04:05"
+func FormatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d:00", minutes/60, minutes%60)
+}
+
+func (s TimeSlot) Overlaps(other TimeSlot) bool {
+	return s.DayOfWeek == other.DayOfWeek && s.Start < other.End && other.Start < s.End
+}
+
+// SectionLabel identifies a section for display, e.g. "CSCI 151 1L"
+func SectionLabel(section SectionWithDetails) string {
+	return section.Course.CourseCode + " " + section.SectionNumber
+}
+
+// SectionSlots returns the meetings of a section as time slots. Meetings with
+// unparseable times are skipped.
+func SectionSlots(section SectionWithDetails) []TimeSlot {
+	sectionID := section.ID
+	slots := make([]TimeSlot, 0, len(section.Meetings))
+	for _, m := range section.Meetings {
+		start, err := ParseClock(m.StartTime)
+		if err != nil {
+			continue
+		}
+		end, err := ParseClock(m.EndTime)
+		if err != nil {
+			continue
+		}
+		slots = append(slots, TimeSlot{
+			DayOfWeek: m.DayOfWeek,
+			Start:     start,
+			End:       end,
+			Label:     SectionLabel(section),
+			SectionID: &sectionID,
+			Building:  m.Building,
+		})
+	}
+	return slots
+}
+
+// Slots returns the meetings of every section and every busy block on the
+// schedule as time slots. Entries with unparseable times are skipped.
+func (s ScheduleWithSections) Slots() []TimeSlot {
+	var slots []TimeSlot
+	for _, section := range s.Sections {
+		slots = append(slots, SectionSlots(section)...)
+	}
+	for _, block := range s.Blocks {
+		slots = append(slots, block.Slots()...)
+	}
+	return slots
+}
+
+// SortSlots orders slots by day of week, then start time
+func SortSlots(slots []TimeSlot) {
+	dayIndex := make(map[string]int, len(Days))
+	for i, d := range Days {
+		dayIndex[d] = i
+	}
+	sort.SliceStable(slots, func(i, j int) bool {
+		if slots[i].DayOfWeek != slots[j].DayOfWeek {
+			return dayIndex[slots[i].DayOfWeek] < dayIndex[slots[j].DayOfWeek]
+		}
+		return slots[i].Start < slots[j].Start
+	})
+}
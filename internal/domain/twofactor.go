@@ -0,0 +1,45 @@
+package domain
+
+import "time"
+
+// TwoFactorEnrollment is shown once when a user starts TOTP enrollment.
+// QRCode is a data:image/png;base64 URL of OTPAuthURI.
+type TwoFactorEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+	QRCode     string `json:"qr_code"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorChallenge is returned by a correct password login when the account
+// needs a second factor. SetupRequired means two-factor is mandatory for the
+// account but not yet enrolled; the challenge token can then be used to
+// enroll before completing the login.
+type TwoFactorChallenge struct {
+	ChallengeToken string    `json:"challenge_token"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	SetupRequired  bool      `json:"setup_required"`
+}
+
+type ChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+}
+
+// TwoFactorLoginRequest completes a challenged login with either an
+// authenticator code or one of the recovery codes
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode   string `json:"recovery_code" validate:"required_without=Code"`
+}
+
+type SetTwoFactorRequiredRequest struct {
+	Required *bool `json:"required" validate:"required"`
+}
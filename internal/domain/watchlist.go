@@ -0,0 +1,63 @@
+package domain
+
+import "time"
+
+// WatchlistItem bookmarks either a single section or a whole course
+type WatchlistItem struct {
+	ID        int       `db:"id" json:"id"`
+	StudentID int       `db:"student_id" json:"student_id"`
+	SectionID *int      `db:"section_id" json:"section_id"`
+	CourseID  *int      `db:"course_id" json:"course_id"`
+	Note      *string   `db:"note" json:"note"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type WatchRequest struct {
+	SectionID *int    `json:"section_id" validate:"required_without=CourseID,excluded_with=CourseID"`
+	CourseID  *int    `json:"course_id" validate:"required_without=SectionID,excluded_with=SectionID"`
+	Note      *string `json:"note" validate:"omitempty,max=500"`
+}
+
+type UpdateWatchRequest struct {
+	Note *string `json:"note" validate:"omitempty,max=500"`
+}
+
+type MoveWatchRequest struct {
+	ScheduleID int  `json:"schedule_id" validate:"required"`
+	SectionID  *int `json:"section_id"` // required when the item watches a course
+	MeetingID  *int `json:"meeting_id"`
+	Keep       bool `json:"keep"` // leave the item on the watchlist
+}
+
+// WatchedSection is a watched section with its live seat count and, when a
+// schedule is selected, how it fits into that schedule
+type WatchedSection struct {
+	SectionWithDetails
+	InSchedule  bool       `json:"in_schedule"`
+	HasConflict bool       `json:"has_conflict"`
+	Conflicts   []Conflict `json:"conflicts,omitempty"`
+}
+
+// WatchlistEntry is an item with the sections it covers: the section itself,
+// or every section of the watched course
+type WatchlistEntry struct {
+	WatchlistItem
+	Sections []WatchedSection `json:"sections"`
+}
+
+// CompareToSchedule marks which sections are already in the schedule and
+// which conflict with it
+func (e *WatchlistEntry) CompareToSchedule(schedule *ScheduleWithSections) {
+	inSchedule := make(map[int]bool, len(schedule.Sections))
+	for _, section := range schedule.Sections {
+		inSchedule[section.ID] = true
+	}
+
+	slots := schedule.Slots()
+	for i := range e.Sections {
+		ws := &e.Sections[i]
+		ws.InSchedule = inSchedule[ws.ID]
+		ws.Conflicts = FindConflictsWith(slots, SectionSlots(ws.SectionWithDetails))
+		ws.HasConflict = len(ws.Conflicts) > 0
+	}
+}
@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"scheduler/internal/domain"
+	"scheduler/internal/mailer"
+	"scheduler/internal/middleware"
+	"scheduler/internal/repository/postgres"
+	"scheduler/internal/utils"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	verifyEmailTTL   = 48 * time.Hour
+	resetPasswordTTL = time.Hour
+
+	// mailTimeout bounds an email sent after the response has gone out
+	mailTimeout = time.Minute
+)
+
+func SetupAccountRoutes(e *echo.Echo, storage *postgres.Storage, m mailer.Mailer, authMiddleware echo.MiddlewareFunc) {
+	// Token guessing and mail flooding are both limited per client IP
+	tokenLimit := middleware.RateLimit("ACCOUNT_TOKEN", 10, 5)
+	mailLimit := middleware.RateLimit("ACCOUNT_MAIL", 5, 3)
+
+	e.POST("/api/auth/verify-email", RequestEmailVerification(storage, m), authMiddleware, mailLimit)
+	e.POST("/api/auth/verify-email/confirm", ConfirmEmailVerification(storage), tokenLimit)
+	e.POST("/api/auth/password-reset", RequestPasswordReset(storage, m), mailLimit)
+	e.POST("/api/auth/password-reset/confirm", ConfirmPasswordReset(storage), tokenLimit)
+}
+
+// appLink builds a link into the frontend at APP_URL (default
+// http://localhost:8080) carrying a token
+func appLink(path, token string) string {
+	base := os.Getenv("APP_URL")
+	if base == "" {
+		base = "http://localhost:8080"
+	}
+	return base + path + "?token=" + url.QueryEscape(token)
+}
+
+// sendAccountToken creates a token for purpose and emails the link to the student
+func sendAccountToken(ctx context.Context, storage *postgres.Storage, m mailer.Mailer, student *domain.Student, purpose string) error {
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	ttl, path, subject, body := verifyEmailTTL, "/verify-email", "Verify your email",
+		"Confirm your email address by opening the link below. It expires in %s.\n\n%s\n"
+	if purpose == domain.TokenResetPassword {
+		ttl, path, subject, body = resetPasswordTTL, "/reset-password", "Reset your password",
+			"Someone asked to reset your password. If it was you, open the link below within %s.\nOtherwise you can ignore this email.\n\n%s\n"
+	}
+
+	err = storage.CreateAccountToken(ctx, student.ID, purpose, utils.HashToken(token), time.Now().Add(ttl))
+	if err != nil {
+		return err
+	}
+
+	return m.Send(ctx, mailer.Message{
+		To:      student.Email,
+		Subject: subject,
+		Body:    fmt.Sprintf("Hi %s,\n\n", student.FirstName) + fmt.Sprintf(body, ttl, appLink(path, token)),
+	})
+}
+
+// sendAccountTokenLater runs sendAccountToken in the background, detached
+// from the request, and only logs a failure
+func sendAccountTokenLater(c echo.Context, storage *postgres.Storage, m mailer.Mailer, student *domain.Student, purpose string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request().Context()), mailTimeout)
+	go func() {
+		defer cancel()
+		if err := sendAccountToken(ctx, storage, m, student, purpose); err != nil {
+			log.Printf("failed to send %s email to student %d: %v", purpose, student.ID, err)
+		}
+	}()
+}
+
+// RequestEmailVerification godoc
+// @Summary Resend the email verification link
+// @Description Email a new verification link to the current user; earlier links stop working
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/verify-email [post]
+func RequestEmailVerification(storage *postgres.Storage, m mailer.Mailer) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		student, err := storage.GetStudentByID(c.Request().Context(), userID)
+		if err != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		if student.EmailVerifiedAt != nil {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "email already verified"})
+		}
+
+		if err := sendAccountToken(c.Request().Context(), storage, m, student, domain.TokenVerifyEmail); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to send verification email"})
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "verification email sent"})
+	}
+}
+
+// ConfirmEmailVerification godoc
+// @Summary Verify an email address
+// @Description Confirm an email address with the token from the verification link
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body domain.TokenRequest true "Verification token"
+// @Success 200 {object} domain.Student
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/verify-email/confirm [post]
+func ConfirmEmailVerification(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req domain.TokenRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		student, err := storage.VerifyEmail(c.Request().Context(), utils.HashToken(req.Token))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid or expired token"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to verify email"})
+		}
+
+		return c.JSON(http.StatusOK, student)
+	}
+}
+
+// RequestPasswordReset godoc
+// @Summary Request a password reset
+// @Description Email a password reset link. The response is the same whether or not the email belongs to an account.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param email body domain.PasswordResetRequest true "Account email"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/password-reset [post]
+func RequestPasswordReset(storage *postgres.Storage, m mailer.Mailer) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req domain.PasswordResetRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		// The mail goes out in the background and failures are only logged,
+		// so neither the response nor its timing reveals whether the account
+		// exists
+		student, err := storage.GetStudentByEmail(c.Request().Context(), req.Email)
+		if err == nil {
+			sendAccountTokenLater(c, storage, m, student, domain.TokenResetPassword)
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "if the account exists, a reset link has been sent"})
+	}
+}
+
+// ConfirmPasswordReset godoc
+// @Summary Reset a password
+// @Description Set a new password with the token from the reset link. All sessions of the account are logged out.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param reset body domain.ConfirmPasswordResetRequest true "Token and new password"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/password-reset/confirm [post]
+func ConfirmPasswordReset(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req domain.ConfirmPasswordResetRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to hash password"})
+		}
+
+		err = storage.ResetPassword(c.Request().Context(), utils.HashToken(req.Token), string(hashedPassword))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid or expired token"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to reset password"})
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "password reset, please log in again"})
+	}
+}
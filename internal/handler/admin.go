@@ -0,0 +1,445 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"scheduler/internal/domain"
+	"scheduler/internal/middleware"
+	"scheduler/internal/repository/postgres"
+	"scheduler/internal/utils"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// SetupAdminRoutes registers registrar-only endpoints under /api/admin
+func SetupAdminRoutes(e *echo.Echo, storage *postgres.Storage, authMiddleware echo.MiddlewareFunc) {
+	g := e.Group("/api/admin", authMiddleware, middleware.RequireRole(domain.RoleAdmin))
+
+	g.PATCH("/users/:id/role", UpdateUserRole(storage))
+	g.PUT("/users/:id/advisor", AssignAdvisor(storage))
+	g.PUT("/users/:id/two-factor", SetTwoFactorRequired(storage))
+	g.DELETE("/users/:id/two-factor", ResetTwoFactor(storage))
+	g.POST("/users/:id/impersonate", ImpersonateUser(storage))
+	g.POST("/schedules/:id/enroll", EnrollSchedule(storage))
+	g.GET("/audit-log", GetAuditLog(storage))
+
+	// Also open to API keys holding the matching scope
+	keyed := e.Group("/api/admin", middleware.APIKeyAuth(storage, authMiddleware))
+	keyed.GET("/users/:id/schedules", GetUserSchedules(storage), middleware.RequireScope(domain.ScopeSchedulesRead, domain.RoleAdmin))
+	keyed.GET("/reports/catalog-integrity", GetCatalogIntegrityReport(storage), middleware.RequireScope(domain.ScopeCatalogRead, domain.RoleAdmin))
+}
+
+// UpdateUserRole godoc
+// @Summary Change a user's role
+// @Description Assign the student, advisor, professor or admin role to a user (admins only). Changing the role logs the user out of every session.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param role body domain.UpdateRoleRequest true "New role"
+// @Success 200 {object} domain.Student
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/users/{id}/role [patch]
+func UpdateUserRole(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user id"})
+		}
+
+		var req domain.UpdateRoleRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		user, err := storage.UpdateStudentRole(c.Request().Context(), userID, req.Role)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update role"})
+		}
+
+		return c.JSON(http.StatusOK, user)
+	}
+}
+
+// AssignAdvisor godoc
+// @Summary Assign an advisor to a student
+// @Description Set the advisor who reviews a student's submitted schedules; a null advisor_id clears it. Users cannot advise themselves (admins only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Student ID"
+// @Param advisor body domain.AssignAdvisorRequest true "Advisor"
+// @Success 200 {object} domain.Student
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/users/{id}/advisor [put]
+func AssignAdvisor(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		studentID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user id"})
+		}
+
+		var req domain.AssignAdvisorRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if req.AdvisorID != nil && *req.AdvisorID == studentID {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "a user cannot be their own advisor"})
+		}
+
+		if req.AdvisorID != nil {
+			advisor, err := storage.GetStudentByID(c.Request().Context(), *req.AdvisorID)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return c.JSON(http.StatusNotFound, map[string]string{"error": "advisor not found"})
+			}
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to get advisor"})
+			}
+
+			if advisor.Role != domain.RoleAdvisor {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "user is not an advisor"})
+			}
+		}
+
+		student, err := storage.AssignAdvisor(c.Request().Context(), studentID, req.AdvisorID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to assign advisor"})
+		}
+
+		return c.JSON(http.StatusOK, student)
+	}
+}
+
+// EnrollSchedule godoc
+// @Summary Enroll an approved schedule
+// @Description Move an approved schedule to enrolled and take a seat in each of its sections (admins only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/schedules/{id}/enroll [post]
+func EnrollSchedule(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		scheduleID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid schedule id"})
+		}
+
+		err = storage.EnrollSchedule(c.Request().Context(), scheduleID)
+		if errors.Is(err, utils.ErrInvalidTransition) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "only approved schedules can be enrolled"})
+		}
+		if errors.Is(err, utils.ErrSectionFull) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to enroll schedule"})
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "schedule enrolled"})
+	}
+}
+
+// SetTwoFactorRequired godoc
+// @Summary Require two-factor for a user
+// @Description Make two-factor mandatory for a user, or optional again. A user without an authenticator is asked to enroll at their next login. Turning it on logs the user out of every session, so they must pass two-factor to get back in. Whole roles can be required with TWO_FACTOR_REQUIRED_ROLES. (admins only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param required body domain.SetTwoFactorRequiredRequest true "Whether two-factor is required"
+// @Success 200 {object} domain.Student
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/users/{id}/two-factor [put]
+func SetTwoFactorRequired(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user id"})
+		}
+
+		var req domain.SetTwoFactorRequiredRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		user, err := storage.SetTwoFactorRequired(c.Request().Context(), userID, *req.Required)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update user"})
+		}
+
+		return c.JSON(http.StatusOK, user)
+	}
+}
+
+// ResetTwoFactor godoc
+// @Summary Reset a user's two-factor
+// @Description Remove the authenticator and recovery codes of a user who lost them. If two-factor is mandatory they enroll again at their next login. (admins only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/users/{id}/two-factor [delete]
+func ResetTwoFactor(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user id"})
+		}
+
+		ctx := c.Request().Context()
+		if err := storage.DisableTOTP(ctx, userID); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to reset two-factor"})
+		}
+
+		adminID, _ := c.Get("user_id").(int)
+		entry := auditEntry(c, domain.AuditTwoFactorDisabled, &userID, "", "reset by admin "+strconv.Itoa(adminID))
+		if err := storage.RecordAuditEvent(ctx, entry); err != nil {
+			log.Printf("failed to record audit event: %v", err)
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "two-factor reset"})
+	}
+}
+
+// ImpersonateUser godoc
+// @Summary Act as a student
+// @Description Issue a token that acts as the student for IMPERSONATION_MINUTES (default 15, at most 60), to see exactly what they see. The token carries an act claim naming the admin, cannot be refreshed and cannot change the password, submit schedules, manage two-factor or sessions, create share links or export data. No token is issued unless the impersonation is written to the audit log, as is every request made with it. Only students can be impersonated, not advisors, professors or admins. (admins only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Student ID"
+// @Param reason body domain.ImpersonateRequest true "Why support needs to act as the student"
+// @Success 200 {object} domain.ImpersonationResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/users/{id}/impersonate [post]
+func ImpersonateUser(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		adminID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+		adminEmail, _ := c.Get("email").(string)
+
+		studentID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user id"})
+		}
+
+		var req domain.ImpersonateRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		ctx := c.Request().Context()
+
+		student, err := storage.GetStudentByID(ctx, studentID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to get user"})
+		}
+
+		// Impersonation is for seeing what a student sees; acting as an advisor
+		// or professor would let an admin sign their reviews
+		if student.Role != domain.RoleStudent {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "only students can be impersonated"})
+		}
+
+		expiresAt := time.Now().Add(utils.ImpersonationTTL())
+		session, err := storage.CreateImpersonationSession(ctx, student.ID, adminID, c.Request().UserAgent(), c.RealIP(), expiresAt)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to start impersonation"})
+		}
+
+		// No token is handed out unless the impersonation is on record
+		detail := fmt.Sprintf("by %s (id %d): %s", adminEmail, adminID, req.Reason)
+		entry := auditEntry(c, domain.AuditImpersonationStarted, &student.ID, student.Email, detail)
+		if err := storage.RecordAuditEvent(ctx, entry); err != nil {
+			log.Printf("failed to record audit event: %v", err)
+			if err := storage.RevokeSession(ctx, session.ID); err != nil {
+				log.Printf("failed to revoke impersonation session %d: %v", session.ID, err)
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to start impersonation"})
+		}
+
+		actor := utils.Actor{UserID: adminID, Email: adminEmail}
+		token, claims, err := utils.GenerateImpersonationToken(student.ID, student.Email, student.Role, session.ID, actor, session.ExpiresAt)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to start impersonation"})
+		}
+
+		return c.JSON(http.StatusOK, domain.ImpersonationResponse{
+			Token:     token,
+			ExpiresAt: claims.ExpiresAt.Time,
+			Student:   *student,
+		})
+	}
+}
+
+// GetUserSchedules godoc
+// @Summary Get a student's schedules
+// @Description Retrieve all schedules belonging to a student (admins, or API keys with schedules:read)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path int true "Student ID"
+// @Success 200 {array} domain.Schedule
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/users/{id}/schedules [get]
+func GetUserSchedules(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		studentID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user id"})
+		}
+
+		if _, err := storage.GetStudentByID(c.Request().Context(), studentID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to get user"})
+		}
+
+		schedules, err := storage.GetStudentSchedules(c.Request().Context(), studentID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch schedules"})
+		}
+
+		return c.JSON(http.StatusOK, schedules)
+	}
+}
+
+// GetCatalogIntegrityReport godoc
+// @Summary Check the course catalog for data errors
+// @Description Report double-booked rooms and professors, sections larger than their rooms and meetings without a room. Meetings ending before they start are never imported; the seeder logs them instead. (admins, or API keys with catalog:read)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Success 200 {object} domain.IntegrityReport
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/reports/catalog-integrity [get]
+func GetCatalogIntegrityReport(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		report, err := storage.GetCatalogIntegrityReport(c.Request().Context())
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to build integrity report"})
+		}
+
+		return c.JSON(http.StatusOK, report)
+	}
+}
+
+// GetAuditLog godoc
+// @Summary List audit log entries
+// @Description Return security events such as failed and locked-out logins, newest first (admins only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param event query string false "Only this event type, e.g. login_failed"
+// @Param student_id query int false "Only entries for this student"
+// @Param limit query int false "Maximum number of entries (default 100, max 500)"
+// @Success 200 {array} domain.AuditEntry
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/audit-log [get]
+func GetAuditLog(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		filter := domain.AuditFilter{Event: c.QueryParam("event"), Limit: 100}
+
+		if raw := c.QueryParam("student_id"); raw != "" {
+			var err error
+			if filter.StudentID, err = strconv.Atoi(raw); err != nil || filter.StudentID < 1 {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid student id"})
+			}
+		}
+
+		if raw := c.QueryParam("limit"); raw != "" {
+			var err error
+			if filter.Limit, err = strconv.Atoi(raw); err != nil || filter.Limit < 1 || filter.Limit > 500 {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+			}
+		}
+
+		entries, err := storage.GetAuditLog(c.Request().Context(), filter)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to get audit log"})
+		}
+
+		return c.JSON(http.StatusOK, entries)
+	}
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"scheduler/internal/domain"
+	"scheduler/internal/middleware"
+	"scheduler/internal/repository/postgres"
+	"scheduler/internal/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+func SetupAdvisorRoutes(e *echo.Echo, storage *postgres.Storage, authMiddleware echo.MiddlewareFunc) {
+	g := e.Group("/api/advisor", authMiddleware, middleware.RequireRole(domain.RoleAdvisor, domain.RoleAdmin))
+
+	g.GET("/schedules", GetAdvisorInbox(storage))
+
+	advisee := g.Group("/schedules/:id", middleware.AdviseeSchedule(storage))
+	advisee.GET("", GetScheduleByID(storage))
+	advisee.POST("/approve", ReviewSchedule(storage, domain.ScheduleApproved))
+	advisee.POST("/request-changes", ReviewSchedule(storage, domain.ScheduleChangesRequested))
+}
+
+// GetAdvisorInbox godoc
+// @Summary Get advisee schedules awaiting review
+// @Description List schedules of the authenticated advisor's students in the given status (submitted by default)
+// @Tags advisor
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Schedule status to filter by (default 'submitted')"
+// @Success 200 {array} domain.AdviseeSchedule
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /advisor/schedules [get]
+func GetAdvisorInbox(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		advisorID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		status := c.QueryParam("status")
+		if status == "" {
+			status = domain.ScheduleSubmitted
+		}
+
+		schedules, err := storage.GetAdviseeSchedules(c.Request().Context(), advisorID, status)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch schedules"})
+		}
+
+		return c.JSON(http.StatusOK, schedules)
+	}
+}
+
+// ReviewSchedule godoc
+// @Summary Approve or request changes to a submitted schedule
+// @Description Record the advisor's decision on an advisee's submitted schedule. Advisors cannot review their own schedules. Requesting changes unlocks the schedule for editing.
+// @Tags advisor
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Param review body domain.ReviewScheduleRequest false "Comment for the student"
+// @Success 200 {object} domain.ScheduleReview
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /advisor/schedules/{id}/approve [post]
+// @Router /advisor/schedules/{id}/request-changes [post]
+func ReviewSchedule(storage *postgres.Storage, decision string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		advisorID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		schedule, ok := c.Get("schedule").(*domain.ScheduleWithSections)
+		if !ok {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "invalid schedule context"})
+		}
+
+		if schedule.StudentID == advisorID {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "cannot review your own schedule"})
+		}
+
+		var req domain.ReviewScheduleRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if decision == domain.ScheduleChangesRequested && (req.Comment == nil || *req.Comment == "") {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "a comment is required when requesting changes"})
+		}
+
+		review, err := storage.ReviewSchedule(c.Request().Context(), schedule.ID, advisorID, decision, req.Comment)
+		if errors.Is(err, utils.ErrInvalidTransition) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "only submitted schedules can be reviewed"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to review schedule"})
+		}
+
+		return c.JSON(http.StatusOK, review)
+	}
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"scheduler/internal/domain"
+	"scheduler/internal/middleware"
+	"scheduler/internal/repository/postgres"
+	"scheduler/internal/utils"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// SetupAPIKeyRoutes registers the admin endpoints for managing service API keys
+func SetupAPIKeyRoutes(e *echo.Echo, storage *postgres.Storage, authMiddleware echo.MiddlewareFunc) {
+	g := e.Group("/api/admin/api-keys", authMiddleware, middleware.RequireRole(domain.RoleAdmin))
+
+	g.GET("", GetAPIKeys(storage))
+	g.POST("", CreateAPIKey(storage))
+	g.DELETE("/:id", RevokeAPIKey(storage))
+}
+
+// GetAPIKeys godoc
+// @Summary List API keys
+// @Description List every service API key, including expired and revoked ones, with when and where each was last used. The keys themselves are never returned. (admins only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} domain.APIKey
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/api-keys [get]
+func GetAPIKeys(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		keys, err := storage.GetAPIKeys(c.Request().Context())
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to get api keys"})
+		}
+
+		return c.JSON(http.StatusOK, keys)
+	}
+}
+
+// CreateAPIKey godoc
+// @Summary Create an API key
+// @Description Issue a key for an internal service, limited to the given scopes (catalog:read, schedules:read, import:write). The key is returned only in this response; send it as X-API-Key or as a Bearer token. (admins only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param key body domain.CreateAPIKeyRequest true "Key name, scopes and optional expiry"
+// @Success 201 {object} domain.CreateAPIKeyResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/api-keys [post]
+func CreateAPIKey(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		adminID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		var req domain.CreateAPIKeyRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "expires_at must be in the future"})
+		}
+
+		key, prefix, err := utils.GenerateAPIKey()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to generate api key"})
+		}
+
+		apiKey, err := storage.CreateAPIKey(c.Request().Context(), req.Name, prefix, utils.HashToken(key), req.Scopes, adminID, req.ExpiresAt)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create api key"})
+		}
+
+		entry := auditEntry(c, domain.AuditAPIKeyCreated, &adminID, "", apiKey.Prefix+" "+apiKey.Name)
+		if err := storage.RecordAuditEvent(c.Request().Context(), entry); err != nil {
+			log.Printf("failed to record audit event: %v", err)
+		}
+
+		return c.JSON(http.StatusCreated, domain.CreateAPIKeyResponse{Key: key, APIKey: *apiKey})
+	}
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description Stop accepting an API key immediately. Revoked keys stay listed. (admins only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "API key ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/api-keys/{id} [delete]
+func RevokeAPIKey(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		adminID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		keyID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid api key id"})
+		}
+
+		apiKey, err := storage.RevokeAPIKey(c.Request().Context(), keyID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "api key not found or already revoked"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to revoke api key"})
+		}
+
+		entry := auditEntry(c, domain.AuditAPIKeyRevoked, &adminID, "", apiKey.Prefix+" "+apiKey.Name)
+		if err := storage.RecordAuditEvent(c.Request().Context(), entry); err != nil {
+			log.Printf("failed to record audit event: %v", err)
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "api key revoked"})
+	}
+}
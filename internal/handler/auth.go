@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"scheduler/internal/domain"
+	"scheduler/internal/repository/postgres"
+	"scheduler/internal/utils"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// newRefreshToken returns a refresh token, its hash for storage and its expiry
+func newRefreshToken() (token, hash string, expiresAt time.Time, err error) {
+	token, err = utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	return token, utils.HashToken(token), time.Now().Add(min(utils.RefreshTokenTTL(), utils.SessionMaxAge())), nil
+}
+
+// startSession opens a login session for the student and returns the access
+// and refresh tokens for it
+func startSession(c echo.Context, storage *postgres.Storage, student *domain.Student) (*domain.AuthResponse, error) {
+	refreshToken, refreshHash, expiresAt, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := storage.CreateSession(c.Request().Context(), student.ID, c.Request().UserAgent(), c.RealIP(), refreshHash, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	token, claims, err := utils.GenerateToken(student.ID, student.Email, student.Role, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.AuthResponse{
+		Token:        token,
+		ExpiresAt:    claims.ExpiresAt.Time,
+		RefreshToken: refreshToken,
+		Student:      *student,
+	}, nil
+}
+
+// auditEntry describes an event caused by the current request
+func auditEntry(c echo.Context, event string, studentID *int, email, detail string) domain.AuditEntry {
+	ip, userAgent := c.RealIP(), c.Request().UserAgent()
+	entry := domain.AuditEntry{Event: event, StudentID: studentID, IPAddress: &ip, UserAgent: &userAgent}
+	if email != "" {
+		entry.Email = &email
+	}
+	if detail != "" {
+		entry.Detail = &detail
+	}
+	return entry
+}
+
+// RefreshToken godoc
+// @Summary Refresh an access token
+// @Description Exchange a refresh token for a new access token and a new refresh token. Each refresh token works once; reusing one revokes its whole session. A session can be refreshed for at most SESSION_MAX_DAYS (default 90) after the login.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param refresh body domain.RefreshRequest true "Refresh token"
+// @Success 200 {object} domain.AuthResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/refresh [post]
+func RefreshToken(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req domain.RefreshRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		refreshToken, refreshHash, expiresAt, err := newRefreshToken()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to generate token"})
+		}
+
+		session, err := storage.RotateRefreshToken(c.Request().Context(), utils.HashToken(req.RefreshToken), refreshHash, expiresAt, utils.SessionMaxAge())
+		if errors.Is(err, utils.ErrTokenReused) {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "refresh token reused, session revoked"})
+		}
+		if errors.Is(err, utils.ErrInvalidToken) || errors.Is(err, utils.ErrExpiredToken) {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to refresh token"})
+		}
+
+		student, err := storage.GetStudentByID(c.Request().Context(), session.StudentID)
+		if err != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": utils.ErrInvalidToken.Error()})
+		}
+
+		token, claims, err := utils.GenerateToken(student.ID, student.Email, student.Role, session.ID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to generate token"})
+		}
+
+		return c.JSON(http.StatusOK, domain.AuthResponse{
+			Token:        token,
+			ExpiresAt:    claims.ExpiresAt.Time,
+			RefreshToken: refreshToken,
+			Student:      *student,
+		})
+	}
+}
+
+// Logout godoc
+// @Summary Log out
+// @Description Revoke the current session: its refresh token stops working and the access token used for this request is rejected from now on
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/logout [post]
+func Logout(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims, ok := c.Get("claims").(*utils.Claims)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		if err := storage.RevokeSession(c.Request().Context(), claims.SessionID); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to log out"})
+		}
+
+		if err := storage.RevokeAccessToken(c.Request().Context(), claims.ID, claims.ExpiresAt.Time); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to log out"})
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "logged out"})
+	}
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"scheduler/internal/domain"
+	"scheduler/internal/middleware"
+	"scheduler/internal/repository/postgres"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+)
+
+func SetupBlockRoutes(e *echo.Echo, storage *postgres.Storage, authMiddleware echo.MiddlewareFunc) {
+	g := e.Group("/api/schedules/:id/blocks", authMiddleware, middleware.ScheduleOwner(storage))
+
+	g.POST("", CreateBusyBlock(storage))
+	g.PUT("/:blockId", UpdateBusyBlock(storage))
+	g.DELETE("/:blockId", DeleteBusyBlock(storage))
+}
+
+// bindBusyBlock binds and validates a block request, writing the error
+// response itself when it returns false
+func bindBusyBlock(c echo.Context, req *domain.BusyBlockRequest) (bool, error) {
+	if err := c.Bind(req); err != nil {
+		return false, c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	if err := c.Validate(req); err != nil {
+		return false, c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	if err := req.CheckTimes(); err != nil {
+		return false, c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	return true, nil
+}
+
+// CreateBusyBlock godoc
+// @Summary Add a busy block to a schedule
+// @Description Add a personal recurring commitment (job, club, practice). Blocks count for conflicts and free time but not for credits or enrollment. Like sections, blocks cannot be changed while the schedule is submitted or approved.
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Param block body domain.BusyBlockRequest true "Block details"
+// @Success 201 {object} domain.BusyBlock
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /schedules/{id}/blocks [post]
+func CreateBusyBlock(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		schedule, ok := c.Get("schedule").(*domain.ScheduleWithSections)
+		if !ok {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "invalid schedule context"})
+		}
+		if !schedule.IsEditable() {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "schedule is locked while " + schedule.Status})
+		}
+
+		var req domain.BusyBlockRequest
+		if ok, err := bindBusyBlock(c, &req); !ok {
+			return err
+		}
+
+		block, err := storage.CreateScheduleBlock(c.Request().Context(), schedule.ID, &req)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create block"})
+		}
+
+		return c.JSON(http.StatusCreated, block)
+	}
+}
+
+// UpdateBusyBlock godoc
+// @Summary Update a busy block
+// @Description Replace the label, days, times and location of a busy block. Not allowed while the schedule is submitted or approved.
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Param blockId path int true "Block ID"
+// @Param block body domain.BusyBlockRequest true "Block details"
+// @Success 200 {object} domain.BusyBlock
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /schedules/{id}/blocks/{blockId} [put]
+func UpdateBusyBlock(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		schedule, ok := c.Get("schedule").(*domain.ScheduleWithSections)
+		if !ok {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "invalid schedule context"})
+		}
+		if !schedule.IsEditable() {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "schedule is locked while " + schedule.Status})
+		}
+
+		blockID, err := strconv.Atoi(c.Param("blockId"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid block id"})
+		}
+
+		var req domain.BusyBlockRequest
+		if ok, err := bindBusyBlock(c, &req); !ok {
+			return err
+		}
+
+		block, err := storage.UpdateScheduleBlock(c.Request().Context(), schedule.ID, blockID, &req)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "block not found"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update block"})
+		}
+
+		return c.JSON(http.StatusOK, block)
+	}
+}
+
+// DeleteBusyBlock godoc
+// @Summary Remove a busy block
+// @Description Remove a busy block from a schedule. Not allowed while the schedule is submitted or approved.
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Param blockId path int true "Block ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /schedules/{id}/blocks/{blockId} [delete]
+func DeleteBusyBlock(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		schedule, ok := c.Get("schedule").(*domain.ScheduleWithSections)
+		if !ok {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "invalid schedule context"})
+		}
+		if !schedule.IsEditable() {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "schedule is locked while " + schedule.Status})
+		}
+
+		blockID, err := strconv.Atoi(c.Param("blockId"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid block id"})
+		}
+
+		err = storage.DeleteScheduleBlock(c.Request().Context(), schedule.ID, blockID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "block not found"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to delete block"})
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "block removed"})
+	}
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"scheduler/internal/domain"
+	"scheduler/internal/middleware"
+	"scheduler/internal/repository/postgres"
+	"scheduler/internal/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+func SetupBuildingRoutes(e *echo.Echo, storage *postgres.Storage, authMiddleware echo.MiddlewareFunc) {
+	e.GET("/api/buildings", GetBuildings(storage))
+	e.GET("/api/buildings/walking-times", GetWalkingTimes(storage))
+
+	// Catalog feeds may update buildings with an import:write API key
+	keyed := e.Group("/api/admin", middleware.APIKeyAuth(storage, authMiddleware))
+	keyed.PUT("/buildings/:code", UpsertBuilding(storage), middleware.RequireScope(domain.ScopeImportWrite, domain.RoleAdmin))
+	keyed.PUT("/walking-times", SetWalkingTime(storage), middleware.RequireScope(domain.ScopeImportWrite, domain.RoleAdmin))
+}
+
+// GetBuildings godoc
+// @Summary Get all buildings
+// @Description List campus buildings with coordinates and accessibility information
+// @Tags buildings
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.Building
+// @Failure 500 {object} map[string]string
+// @Router /buildings [get]
+func GetBuildings(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		buildings, err := storage.GetBuildings(c.Request().Context())
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch buildings"})
+		}
+
+		return c.JSON(http.StatusOK, buildings)
+	}
+}
+
+// GetWalkingTimes godoc
+// @Summary Get configured walking times
+// @Description List walking minutes between pairs of buildings. Pairs not listed are estimated from coordinates.
+// @Tags buildings
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.WalkingTime
+// @Failure 500 {object} map[string]string
+// @Router /buildings/walking-times [get]
+func GetWalkingTimes(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		times, err := storage.GetWalkingTimes(c.Request().Context())
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch walking times"})
+		}
+
+		return c.JSON(http.StatusOK, times)
+	}
+}
+
+// UpsertBuilding godoc
+// @Summary Create or update a building
+// @Description Set a building's name, coordinates and accessibility information (admins, or API keys with import:write)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param code path string true "Building code (e.g. 'C3')"
+// @Param building body domain.UpdateBuildingRequest true "Building details"
+// @Success 200 {object} domain.Building
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/buildings/{code} [put]
+func UpsertBuilding(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req domain.UpdateBuildingRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		building, err := storage.UpsertBuilding(c.Request().Context(), c.Param("code"), &req)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to save building"})
+		}
+
+		return c.JSON(http.StatusOK, building)
+	}
+}
+
+// SetWalkingTime godoc
+// @Summary Set walking time between buildings
+// @Description Set the walking minutes between two buildings, in either direction (admins, or API keys with import:write)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param walkingTime body domain.SetWalkingTimeRequest true "Walking time"
+// @Success 200 {object} domain.WalkingTime
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/walking-times [put]
+func SetWalkingTime(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req domain.SetWalkingTimeRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		walkingTime, err := storage.SetWalkingTime(c.Request().Context(), &req)
+		if errors.Is(err, utils.ErrUnknownBuilding) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to set walking time"})
+		}
+
+		return c.JSON(http.StatusOK, walkingTime)
+	}
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"scheduler/internal/domain"
+	"scheduler/internal/repository/postgres"
+
+	"github.com/labstack/echo/v4"
+)
+
+// checkCreditLoad validates a schedule's credit total against the student's
+// per-term limit, honoring any approved overload petition for the schedule
+func checkCreditLoad(ctx context.Context, storage *postgres.Storage, studentID, scheduleID, totalCredits int, submitting bool) ([]domain.ValidationError, error) {
+	student, err := storage.GetStudentByID(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := storage.GetCreditLimit(ctx, student.YearOfStudy, student.Standing)
+	if err != nil {
+		return nil, err
+	}
+
+	approvedCredits, err := storage.GetApprovedOverloadCredits(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	return limit.Check(totalCredits, approvedCredits, submitting), nil
+}
+
+func scheduleHasCourse(schedule *domain.ScheduleWithSections, courseID int) bool {
+	for _, section := range schedule.Sections {
+		if section.CourseID == courseID {
+			return true
+		}
+	}
+	return false
+}
+
+// GetMyCreditLimit godoc
+// @Summary Get credit limit for current student
+// @Description Get the minimum and maximum credits per term for the authenticated student's year and standing
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} domain.CreditLimit
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/credit-limit [get]
+func GetMyCreditLimit(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		studentID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		student, err := storage.GetStudentByID(c.Request().Context(), studentID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch student"})
+		}
+
+		limit, err := storage.GetCreditLimit(c.Request().Context(), student.YearOfStudy, student.Standing)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch credit limit"})
+		}
+
+		return c.JSON(http.StatusOK, limit)
+	}
+}
@@ -0,0 +1,391 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"scheduler/internal/domain"
+	"scheduler/internal/middleware"
+	"scheduler/internal/repository/postgres"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+)
+
+func SetupGroupRoutes(e *echo.Echo, storage *postgres.Storage, authMiddleware echo.MiddlewareFunc) {
+	g := e.Group("/api/groups", authMiddleware)
+
+	g.GET("", GetMyGroups(storage))
+	g.POST("", CreateGroup(storage))
+	g.POST("/:id/join", JoinGroup(storage))
+	g.POST("/:id/leave", LeaveGroup(storage))
+
+	member := g.Group("/:id", middleware.GroupMember(storage))
+	member.GET("", GetGroup(storage))
+	member.POST("/invites", InviteToGroup(storage))
+	member.PUT("/schedule", SetGroupSchedule(storage))
+	member.GET("/free-time", GetGroupFreeTime(storage))
+}
+
+// CreateGroup godoc
+// @Summary Create a study group
+// @Description Create a group with the authenticated student as its first member
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param group body domain.CreateGroupRequest true "Group details"
+// @Success 201 {object} domain.StudyGroup
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /groups [post]
+func CreateGroup(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		studentID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		var req domain.CreateGroupRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		group, err := storage.CreateGroup(c.Request().Context(), studentID, req.Name)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create group"})
+		}
+
+		return c.JSON(http.StatusCreated, group)
+	}
+}
+
+// GetMyGroups godoc
+// @Summary Get study groups for current student
+// @Description List groups the authenticated student has joined or been invited to
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} domain.StudyGroup
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /groups [get]
+func GetMyGroups(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		studentID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		groups, err := storage.GetStudentGroups(c.Request().Context(), studentID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch groups"})
+		}
+
+		return c.JSON(http.StatusOK, groups)
+	}
+}
+
+// GetGroup godoc
+// @Summary Get a study group
+// @Description Get a group and its members (names, status and whether they picked a schedule)
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Group ID"
+// @Success 200 {object} domain.GroupWithMembers
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /groups/{id} [get]
+func GetGroup(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		group, ok := c.Get("group").(*domain.StudyGroup)
+		if !ok {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "invalid group context"})
+		}
+
+		members, err := storage.GetGroupMembers(c.Request().Context(), group.ID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch members"})
+		}
+
+		return c.JSON(http.StatusOK, domain.GroupWithMembers{StudyGroup: *group, Members: members})
+	}
+}
+
+// InviteToGroup godoc
+// @Summary Invite a student to a group
+// @Description Invite another student by email. Any member may invite. The response is the same whether or not the email belongs to an account.
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Group ID"
+// @Param invite body domain.InviteMemberRequest true "Invitee email"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /groups/{id}/invites [post]
+func InviteToGroup(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		group, ok := c.Get("group").(*domain.StudyGroup)
+		if !ok {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "invalid group context"})
+		}
+
+		var req domain.InviteMemberRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		// An unknown email gets the same answer as a known one, so members
+		// cannot use invitations to find out who has an account
+		invitee, err := storage.GetStudentByEmail(c.Request().Context(), req.Email)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusOK, map[string]string{"message": "if the student has an account, they have been invited"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to invite student"})
+		}
+
+		err = storage.InviteToGroup(c.Request().Context(), group.ID, invitee.ID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to invite student"})
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "if the student has an account, they have been invited"})
+	}
+}
+
+// JoinGroup godoc
+// @Summary Accept a group invitation
+// @Description Join a group the authenticated student has been invited to
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Group ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /groups/{id}/join [post]
+func JoinGroup(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		studentID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		groupID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid group id"})
+		}
+
+		err = storage.JoinGroup(c.Request().Context(), groupID, studentID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "invitation not found"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to join group"})
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "joined group"})
+	}
+}
+
+// LeaveGroup godoc
+// @Summary Leave a group
+// @Description Leave a group or decline an invitation
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Group ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /groups/{id}/leave [post]
+func LeaveGroup(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		studentID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		groupID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid group id"})
+		}
+
+		err = storage.LeaveGroup(c.Request().Context(), groupID, studentID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to leave group"})
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "left group"})
+	}
+}
+
+// SetGroupSchedule godoc
+// @Summary Share a schedule with a group
+// @Description Pick which of the authenticated student's schedules the group uses for free-time search
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Group ID"
+// @Param schedule body domain.SetGroupScheduleRequest true "Schedule to share"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /groups/{id}/schedule [put]
+func SetGroupSchedule(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		studentID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		group, ok := c.Get("group").(*domain.StudyGroup)
+		if !ok {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "invalid group context"})
+		}
+
+		var req domain.SetGroupScheduleRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		schedule, err := storage.GetScheduleWithSections(c.Request().Context(), req.ScheduleID)
+		if err != nil {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "schedule not found"})
+		}
+
+		if schedule.StudentID != studentID {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "access denied"})
+		}
+
+		err = storage.SetGroupSchedule(c.Request().Context(), group.ID, studentID, schedule.ID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to share schedule"})
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "schedule shared with group"})
+	}
+}
+
+// GetGroupFreeTime godoc
+// @Summary Find common free time for a group
+// @Description Intersect the free time of every joined member's shared schedule within a daily window
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Group ID"
+// @Param days query string false "Comma-separated days (default 'Monday,Tuesday,Wednesday,Thursday,Friday')"
+// @Param from query string false "Window start, HH:MM (default '08:00')"
+// @Param to query string false "Window end, HH:MM (default '20:00')"
+// @Param min_minutes query int false "Minimum free slot length in minutes (default 30)"
+// @Success 200 {object} domain.GroupFreeTime
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /groups/{id}/free-time [get]
+func GetGroupFreeTime(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		group, ok := c.Get("group").(*domain.StudyGroup)
+		if !ok {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "invalid group context"})
+		}
+
+		days := domain.Days[:5]
+		if raw := c.QueryParam("days"); raw != "" {
+			days = strings.Split(raw, ",")
+			for _, day := range days {
+				if !slices.Contains(domain.Days, day) {
+					return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid day " + day})
+				}
+			}
+		}
+
+		windowStart, windowEnd := 8*60, 20*60
+		var err error
+		if raw := c.QueryParam("from"); raw != "" {
+			if windowStart, err = domain.ParseClock(raw); err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid from time"})
+			}
+		}
+		if raw := c.QueryParam("to"); raw != "" {
+			if windowEnd, err = domain.ParseClock(raw); err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid to time"})
+			}
+		}
+		if windowEnd <= windowStart {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "to must be after from"})
+		}
+
+		minMinutes := 30
+		if raw := c.QueryParam("min_minutes"); raw != "" {
+			if minMinutes, err = strconv.Atoi(raw); err != nil || minMinutes < 1 {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid min_minutes"})
+			}
+		}
+
+		members, err := storage.GetGroupMembers(c.Request().Context(), group.ID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch members"})
+		}
+
+		var busy []domain.TimeSlot
+		included := 0
+		for _, m := range members {
+			if m.Status != domain.MemberJoined || m.ScheduleID == nil {
+				continue
+			}
+
+			schedule, err := storage.GetScheduleWithSections(c.Request().Context(), *m.ScheduleID)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch schedule"})
+			}
+
+			busy = append(busy, schedule.Slots()...)
+			included++
+		}
+
+		return c.JSON(http.StatusOK, domain.GroupFreeTime{
+			MembersIncluded: included,
+			FreeSlots:       domain.FreeTime(busy, days, windowStart, windowEnd, minMinutes),
+		})
+	}
+}
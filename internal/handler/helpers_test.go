@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"scheduler/internal/domain"
+	"scheduler/internal/repository/postgres"
+	"scheduler/internal/utils"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type testValidator struct {
+	validator *validator.Validate
+}
+
+func (v *testValidator) Validate(i any) error {
+	return v.validator.Struct(i)
+}
+
+func newTestEcho() *echo.Echo {
+	e := echo.New()
+	e.Validator = &testValidator{validator: validator.New()}
+	return e
+}
+
+// testStorage connects to TEST_DATABASE_URL and applies the schema. Tests
+// that need a database are skipped when it is not set; never point it at a
+// database whose data matters.
+func testStorage(t *testing.T) *postgres.Storage {
+	t.Helper()
+
+	connString := os.Getenv("TEST_DATABASE_URL")
+	if connString == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	storage, err := postgres.NewConnection(connString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(storage.Close)
+
+	if err := storage.RunMigrations(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return storage
+}
+
+// useTestSigningKey lets startSession issue access tokens without the rotator
+func useTestSigningKey(t *testing.T) {
+	t.Helper()
+
+	key, err := utils.NewSigningKey(utils.AlgEdDSA, time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.Keys().Replace([]*utils.SigningKey{key})
+}
+
+// createTestStudent registers a student with a unique email and student ID
+// and deletes it when the test ends
+func createTestStudent(t *testing.T, storage *postgres.Storage) *domain.Student {
+	t.Helper()
+
+	suffix := utils.HashToken(t.Name() + time.Now().String())[:10]
+	req := &domain.RegisterRequest{
+		Email:       "test-" + suffix + "@nu.edu.kz",
+		FirstName:   "Test",
+		LastName:    "Student",
+		StudentID:   "t" + suffix,
+		YearOfStudy: 1,
+	}
+
+	student, err := storage.CreateStudent(context.Background(), req, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { storage.DeleteStudent(context.Background(), student.ID) })
+
+	return student
+}
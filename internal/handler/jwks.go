@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"net/http"
+	"scheduler/internal/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+func SetupJWKSRoutes(e *echo.Echo) {
+	e.GET("/.well-known/jwks.json", GetJWKS())
+}
+
+// GetJWKS serves the JSON Web Key Set other services use to verify access
+// tokens. It lives outside /api, so it is not part of the Swagger docs. The
+// set includes keys that are about to take over signing, so caching it for a
+// few minutes is safe.
+func GetJWKS() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Response().Header().Set("Cache-Control", "public, max-age=300")
+		return c.JSON(http.StatusOK, utils.Keys().JWKS())
+	}
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"scheduler/internal/repository/postgres"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+)
+
+func SetupNotificationRoutes(e *echo.Echo, storage *postgres.Storage, authMiddleware echo.MiddlewareFunc) {
+	g := e.Group("/api/notifications", authMiddleware)
+
+	g.GET("", GetNotifications(storage))
+	g.POST("/read", MarkAllNotificationsRead(storage))
+	g.POST("/:notificationId/read", MarkNotificationRead(storage))
+}
+
+// GetNotifications godoc
+// @Summary Get my notifications
+// @Description List the in-app notification feed, newest first
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param unread query bool false "Only unread notifications"
+// @Param limit query int false "Maximum number of notifications (default 50, max 200)"
+// @Success 200 {array} domain.Notification
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /notifications [get]
+func GetNotifications(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		limit := 50
+		if raw := c.QueryParam("limit"); raw != "" {
+			var err error
+			if limit, err = strconv.Atoi(raw); err != nil || limit < 1 || limit > 200 {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+			}
+		}
+
+		notifications, err := storage.GetNotifications(c.Request().Context(), userID, c.QueryParam("unread") == "true", limit)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch notifications"})
+		}
+
+		return c.JSON(http.StatusOK, notifications)
+	}
+}
+
+// MarkNotificationRead godoc
+// @Summary Mark a notification as read
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param notificationId path int true "Notification ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /notifications/{notificationId}/read [post]
+func MarkNotificationRead(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		notificationID, err := strconv.Atoi(c.Param("notificationId"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid notification id"})
+		}
+
+		err = storage.MarkNotificationRead(c.Request().Context(), userID, notificationID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "notification not found"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update notification"})
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "notification marked as read"})
+	}
+}
+
+// MarkAllNotificationsRead godoc
+// @Summary Mark all notifications as read
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /notifications/read [post]
+func MarkAllNotificationsRead(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		if err := storage.MarkAllNotificationsRead(c.Request().Context(), userID); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update notifications"})
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "notifications marked as read"})
+	}
+}
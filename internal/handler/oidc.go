@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"scheduler/internal/domain"
+	"scheduler/internal/middleware"
+	"scheduler/internal/oidc"
+	"scheduler/internal/repository/postgres"
+	"scheduler/internal/utils"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+)
+
+const oidcLoginTTL = 10 * time.Minute
+
+// oidcStateCookie holds the hash of the state of the login this browser
+// started, so a callback carrying someone else's code and state is refused
+const oidcStateCookie = "oidc_state"
+
+func setOIDCStateCookie(c echo.Context, value string, maxAge int) {
+	c.SetCookie(&http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    value,
+		Path:     "/api/auth/oidc",
+		MaxAge:   maxAge,
+		Secure:   c.Scheme() == "https",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// SetupOIDCRoutes registers single sign-on. provider is nil when SSO is not
+// configured, in which case the endpoints answer 404.
+func SetupOIDCRoutes(e *echo.Echo, storage *postgres.Storage, provider *oidc.Provider) {
+	limit := middleware.RateLimit("LOGIN", 20, 10)
+	e.GET("/api/auth/oidc/login", StartOIDCLogin(storage, provider), limit)
+	e.POST("/api/auth/oidc/callback", CompleteOIDCLogin(storage, provider), limit)
+}
+
+// StartOIDCLogin godoc
+// @Summary Start single sign-on
+// @Description Redirect the browser to the university identity provider. After login the provider sends the browser to OIDC_REDIRECT_URL with code and state, which the frontend posts to /auth/oidc/callback with credentials, so the oidc_state cookie set here is sent along.
+// @Tags auth
+// @Success 302
+// @Failure 404 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /auth/oidc/login [get]
+func StartOIDCLogin(storage *postgres.Storage, provider *oidc.Provider) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if provider == nil {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": oidc.ErrNotConfigured.Error()})
+		}
+
+		state, err := utils.GenerateRandomToken(32)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to start login"})
+		}
+		nonce, err := utils.GenerateRandomToken(32)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to start login"})
+		}
+		verifier, err := utils.GenerateRandomToken(48)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to start login"})
+		}
+
+		ctx := c.Request().Context()
+		authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+		if err != nil {
+			log.Printf("oidc: %v", err)
+			return c.JSON(http.StatusBadGateway, map[string]string{"error": "identity provider unavailable"})
+		}
+
+		stateHash := utils.HashToken(state)
+		if err := storage.CreateOIDCLogin(ctx, stateHash, nonce, verifier, time.Now().Add(oidcLoginTTL)); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to start login"})
+		}
+
+		setOIDCStateCookie(c, stateHash, int(oidcLoginTTL.Seconds()))
+
+		return c.Redirect(http.StatusFound, authURL)
+	}
+}
+
+// CompleteOIDCLogin godoc
+// @Summary Finish single sign-on
+// @Description Exchange the code and state from the identity provider redirect for tokens. The state must belong to the login started by this browser, as recorded in the oidc_state cookie. A first login links the account with the same email if the provider verified it; if that account never verified its email itself, its password, two-factor and sessions are removed so only the linked identity can sign in. Otherwise it creates a new account from the provider's claims. Accounts with two-factor get 202 and a challenge, as with password login.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param callback body domain.OIDCCallbackRequest true "Code and state from the provider"
+// @Success 200 {object} domain.AuthResponse
+// @Success 202 {object} domain.TwoFactorChallenge
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /auth/oidc/callback [post]
+func CompleteOIDCLogin(storage *postgres.Storage, provider *oidc.Provider) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if provider == nil {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": oidc.ErrNotConfigured.Error()})
+		}
+
+		var req domain.OIDCCallbackRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		// Without this check an attacker could post their own code and state
+		// from the victim's browser and log the victim into their account
+		stateHash := utils.HashToken(req.State)
+		cookie, err := c.Cookie(oidcStateCookie)
+		if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(stateHash)) != 1 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "login was not started in this browser, start again"})
+		}
+		setOIDCStateCookie(c, "", -1)
+
+		ctx := c.Request().Context()
+		nonce, verifier, err := storage.ConsumeOIDCLogin(ctx, stateHash)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid or expired login, start again"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to log in"})
+		}
+
+		identity, err := provider.Exchange(ctx, req.Code, verifier, nonce)
+		if err != nil {
+			log.Printf("oidc: %v", err)
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "identity provider rejected the login"})
+		}
+
+		if identity.Email == "" || !provider.AllowsEmail(identity.Email) {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "this email domain may not sign in"})
+		}
+
+		if identity.GivenName == "" {
+			identity.GivenName, _, _ = strings.Cut(identity.Email, "@")
+		}
+		// Accounts need a unique student ID; without one from the provider,
+		// derive a stable placeholder from the identity
+		provisionID := "sso-" + utils.HashToken(identity.Issuer + "|" + identity.Subject)[:16]
+
+		student, linked, created, err := storage.LoginWithOIDC(ctx, *identity, provisionID)
+		if errors.Is(err, utils.ErrUnverifiedEmail) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "an account with this email exists; log in with your password to use it"})
+		}
+		if errors.Is(err, utils.ErrStudentIDTaken) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "an account with your student ID already exists under another email; log in with it or contact the registrar"})
+		}
+		if errors.Is(err, utils.ErrInvalidStudentID) {
+			log.Printf("oidc: student ID claim of %s %s is too long", identity.Issuer, identity.Subject)
+			return c.JSON(http.StatusBadGateway, map[string]string{"error": err.Error()})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to log in"})
+		}
+
+		event := ""
+		switch {
+		case linked:
+			event = domain.AuditSSOLinked
+		case created:
+			event = domain.AuditSSOProvisioned
+		}
+		if event != "" {
+			entry := auditEntry(c, event, &student.ID, student.Email, identity.Issuer+" "+identity.Subject)
+			if err := storage.RecordAuditEvent(ctx, entry); err != nil {
+				log.Printf("failed to record audit event: %v", err)
+			}
+		}
+
+		if student.TwoFactorEnabled || twoFactorRequired(student) {
+			challenge, err := startChallenge(c, storage, student)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to log in"})
+			}
+			return c.JSON(http.StatusAccepted, challenge)
+		}
+
+		response, err := startSession(c, storage, student)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to generate token"})
+		}
+
+		return c.JSON(http.StatusOK, response)
+	}
+}
@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"scheduler/internal/domain"
+	"scheduler/internal/oidc"
+	"scheduler/internal/oidc/oidctest"
+	"scheduler/internal/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func newTestProvider(t *testing.T) (*oidc.Provider, *oidctest.Issuer) {
+	t.Helper()
+
+	issuer, err := oidctest.NewIssuer("scheduler")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(issuer.Close)
+
+	provider := oidc.NewProvider(oidc.Config{
+		IssuerURL:      issuer.URL(),
+		ClientID:       issuer.ClientID,
+		RedirectURL:    "http://localhost:8080/oidc/callback",
+		Scopes:         []string{"openid", "email", "profile"},
+		AllowedDomains: []string{"nu.edu.kz"},
+		StudentIDClaim: "student_id",
+	})
+	return provider, issuer
+}
+
+// ssoLogin runs the whole browser flow: start the login, approve it at the
+// provider with claims and post the callback with the state cookie
+func ssoLogin(t *testing.T, e *echo.Echo, issuer *oidctest.Issuer, claims map[string]any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	start := httptest.NewRecorder()
+	e.ServeHTTP(start, httptest.NewRequest(http.MethodGet, "/api/auth/oidc/login", nil))
+	if start.Code != http.StatusFound {
+		t.Fatalf("login start = %d: %s", start.Code, start.Body)
+	}
+
+	code, state, err := issuer.Authorize(start.Header().Get("Location"), claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := json.Marshal(domain.OIDCCallbackRequest{Code: code, State: state})
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/oidc/callback", strings.NewReader(string(body)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	for _, cookie := range start.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCompleteOIDCLoginWithoutStateCookie(t *testing.T) {
+	provider, _ := newTestProvider(t)
+
+	e := newTestEcho()
+	SetupOIDCRoutes(e, nil, provider)
+
+	// An attacker's code and state posted from a browser that never started
+	// a login
+	body := `{"code": "attacker-code", "state": "attacker-state"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/oidc/callback", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestCompleteOIDCLogin(t *testing.T) {
+	storage := testStorage(t)
+	useTestSigningKey(t)
+	provider, issuer := newTestProvider(t)
+
+	e := newTestEcho()
+	SetupOIDCRoutes(e, storage, provider)
+
+	t.Run("links existing account with verified email", func(t *testing.T) {
+		existing := createTestStudent(t, storage)
+
+		rec := ssoLogin(t, e, issuer, map[string]any{
+			"sub":            "link-" + existing.StudentID,
+			"email":          existing.Email,
+			"email_verified": true,
+		})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+		}
+
+		var response domain.AuthResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if response.Student.ID != existing.ID {
+			t.Errorf("logged in as student %d, want %d", response.Student.ID, existing.ID)
+		}
+		if response.Token == "" || response.Student.EmailVerifiedAt == nil {
+			t.Errorf("token %q, email verified at %v", response.Token, response.Student.EmailVerifiedAt)
+		}
+	})
+
+	t.Run("takes over unverified account", func(t *testing.T) {
+		// Someone registered the address with their own password and never
+		// verified it, then kept a session open
+		existing := createTestStudent(t, storage)
+		ctx := context.Background()
+		if err := storage.ChangePassword(ctx, existing.ID, "squatter-hash", 0); err != nil {
+			t.Fatal(err)
+		}
+		squatter, err := storage.CreateSession(ctx, existing.ID, "test", "127.0.0.1", utils.HashToken(t.Name()+time.Now().String()), time.Now().Add(time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rec := ssoLogin(t, e, issuer, map[string]any{
+			"sub":            "claim-" + existing.StudentID,
+			"email":          existing.Email,
+			"email_verified": true,
+		})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+		}
+
+		if hash, err := storage.GetPasswordHash(ctx, existing.ID); err != nil || hash != "" {
+			t.Errorf("password hash = %q, %v; want it cleared", hash, err)
+		}
+
+		sessions, err := storage.GetActiveSessions(ctx, existing.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, session := range sessions {
+			if session.ID == squatter.ID {
+				t.Errorf("session %d from before the link is still active", squatter.ID)
+			}
+		}
+	})
+
+	t.Run("keeps password of verified account", func(t *testing.T) {
+		existing := createTestStudent(t, storage)
+		ctx := context.Background()
+		if err := storage.ChangePassword(ctx, existing.ID, "owner-hash", 0); err != nil {
+			t.Fatal(err)
+		}
+		token := utils.HashToken(t.Name() + time.Now().String())
+		if err := storage.CreateAccountToken(ctx, existing.ID, domain.TokenVerifyEmail, token, time.Now().Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := storage.VerifyEmail(ctx, token); err != nil {
+			t.Fatal(err)
+		}
+
+		rec := ssoLogin(t, e, issuer, map[string]any{
+			"sub":            "verified-" + existing.StudentID,
+			"email":          existing.Email,
+			"email_verified": true,
+		})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+		}
+
+		if hash, err := storage.GetPasswordHash(ctx, existing.ID); err != nil || hash != "owner-hash" {
+			t.Errorf("password hash = %q, %v; want it kept", hash, err)
+		}
+	})
+
+	t.Run("provisions new account", func(t *testing.T) {
+		suffix := utils.HashToken(t.Name() + time.Now().String())[:8]
+		email := "sso-" + suffix + "@nu.edu.kz"
+
+		rec := ssoLogin(t, e, issuer, map[string]any{
+			"sub":            "new-" + suffix,
+			"email":          email,
+			"email_verified": true,
+			"given_name":     "Dana",
+			"family_name":    "Sultanova",
+			"student_id":     "s" + suffix,
+		})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+		}
+
+		var response domain.AuthResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { storage.DeleteStudent(context.Background(), response.Student.ID) })
+
+		student := response.Student
+		if student.Email != email || student.StudentID != "s"+suffix {
+			t.Errorf("provisioned %s %s", student.Email, student.StudentID)
+		}
+		if student.FirstName != "Dana" || student.LastName != "Sultanova" {
+			t.Errorf("provisioned name %s %s", student.FirstName, student.LastName)
+		}
+	})
+
+	t.Run("two-factor account gets a challenge", func(t *testing.T) {
+		existing := createTestStudent(t, storage)
+		if _, err := storage.SetTwoFactorRequired(context.Background(), existing.ID, true); err != nil {
+			t.Fatal(err)
+		}
+
+		rec := ssoLogin(t, e, issuer, map[string]any{
+			"sub":            "2fa-" + existing.StudentID,
+			"email":          existing.Email,
+			"email_verified": true,
+		})
+		if rec.Code != http.StatusAccepted {
+			t.Fatalf("status = %d, want 202: %s", rec.Code, rec.Body)
+		}
+
+		var challenge domain.TwoFactorChallenge
+		if err := json.Unmarshal(rec.Body.Bytes(), &challenge); err != nil {
+			t.Fatal(err)
+		}
+		if challenge.ChallengeToken == "" || !challenge.SetupRequired {
+			t.Errorf("challenge = %+v", challenge)
+		}
+	})
+}
@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"scheduler/internal/domain"
+	"scheduler/internal/middleware"
+	"scheduler/internal/repository/postgres"
+	"scheduler/internal/utils"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+)
+
+func SetupPetitionRoutes(e *echo.Echo, storage *postgres.Storage, authMiddleware echo.MiddlewareFunc) {
+	e.GET("/api/users/me/credit-limit", GetMyCreditLimit(storage), authMiddleware)
+	e.POST("/api/schedules/:id/petitions", CreateOverloadPetition(storage), authMiddleware, middleware.ScheduleOwner(storage))
+
+	g := e.Group("/api/petitions", authMiddleware)
+
+	g.GET("", GetMyPetitions(storage))
+
+	reviewers := g.Group("", middleware.RequireRole(domain.RoleAdvisor, domain.RoleAdmin))
+	reviewers.GET("/pending", GetPendingPetitions(storage))
+	reviewers.PATCH("/:id/review", ReviewPetition(storage))
+}
+
+// CreateOverloadPetition godoc
+// @Summary Request a credit overload
+// @Description Petition an advisor for permission to exceed the credit limit on a schedule. A schedule can have only one pending petition at a time.
+// @Tags petitions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Param petition body domain.CreatePetitionRequest true "Petition details"
+// @Success 201 {object} domain.OverloadPetition
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /schedules/{id}/petitions [post]
+func CreateOverloadPetition(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		schedule, ok := c.Get("schedule").(*domain.ScheduleWithSections)
+		if !ok {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "invalid schedule context"})
+		}
+
+		var req domain.CreatePetitionRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		student, err := storage.GetStudentByID(c.Request().Context(), schedule.StudentID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch student"})
+		}
+
+		limit, err := storage.GetCreditLimit(c.Request().Context(), student.YearOfStudy, student.Standing)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch credit limit"})
+		}
+
+		if req.RequestedCredits <= limit.MaxCredits {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "requested credits are within the normal limit"})
+		}
+
+		petition, err := storage.CreateOverloadPetition(c.Request().Context(), schedule.StudentID, schedule.ID, &req)
+		if errors.Is(err, utils.ErrPetitionPending) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create petition"})
+		}
+
+		return c.JSON(http.StatusCreated, petition)
+	}
+}
+
+// GetMyPetitions godoc
+// @Summary Get overload petitions for current student
+// @Description List all overload petitions filed by the authenticated student
+// @Tags petitions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} domain.OverloadPetition
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /petitions [get]
+func GetMyPetitions(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		studentID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		petitions, err := storage.GetStudentPetitions(c.Request().Context(), studentID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch petitions"})
+		}
+
+		return c.JSON(http.StatusOK, petitions)
+	}
+}
+
+// GetPendingPetitions godoc
+// @Summary Get pending overload petitions
+// @Description List petitions awaiting a decision. Advisors see those of their advisees, admins see all.
+// @Tags petitions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} domain.OverloadPetition
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /petitions/pending [get]
+func GetPendingPetitions(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		// Advisors only see petitions they are allowed to review
+		var advisorID *int
+		if c.Get("role") != domain.RoleAdmin {
+			userID, ok := c.Get("user_id").(int)
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+			}
+			advisorID = &userID
+		}
+
+		petitions, err := storage.GetPendingPetitions(c.Request().Context(), advisorID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch petitions"})
+		}
+
+		return c.JSON(http.StatusOK, petitions)
+	}
+}
+
+// ReviewPetition godoc
+// @Summary Approve or deny an overload petition
+// @Description Record an advisor decision and comment on a pending petition. Only the student's assigned advisor or an admin may review it.
+// @Tags petitions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Petition ID"
+// @Param review body domain.ReviewPetitionRequest true "Decision"
+// @Success 200 {object} domain.OverloadPetition
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /petitions/{id}/review [patch]
+func ReviewPetition(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		petitionID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid petition id"})
+		}
+
+		var req domain.ReviewPetitionRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		petition, err := storage.GetPetitionByID(c.Request().Context(), petitionID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "petition not found"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch petition"})
+		}
+
+		if petition.StudentID == userID {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "cannot review your own petition"})
+		}
+
+		advises, err := middleware.AdvisesStudent(c, storage, petition.StudentID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to check access"})
+		}
+		if !advises {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "only the student's advisor can review this petition"})
+		}
+
+		petition, err = storage.ReviewPetition(c.Request().Context(), petitionID, userID, &req)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "petition already reviewed"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to review petition"})
+		}
+
+		return c.JSON(http.StatusOK, petition)
+	}
+}
@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"scheduler/internal/domain"
+	"scheduler/internal/middleware"
+	"scheduler/internal/repository/postgres"
+	"scheduler/internal/utils"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// SetupProfileRoutes registers the endpoints through which students manage
+// their own account
+func SetupProfileRoutes(e *echo.Echo, storage *postgres.Storage, authMiddleware echo.MiddlewareFunc) {
+	// Both take the current password, so guessing it is limited per client IP
+	passwordLimit := middleware.RateLimit("PASSWORD", 10, 5)
+
+	g := e.Group("/api/users/me", authMiddleware)
+	g.PATCH("", UpdateProfile(storage))
+	g.DELETE("", DeleteAccount(storage), middleware.BlockImpersonation(), passwordLimit)
+	g.POST("/password", ChangePassword(storage), middleware.BlockImpersonation(), passwordLimit)
+	g.GET("/export", ExportAccount(storage), middleware.BlockImpersonation())
+}
+
+// checkPassword reports whether password is the student's current password.
+// Accounts created through single sign-on have none until they reset it, for
+// which it returns utils.ErrNoPassword.
+func checkPassword(c echo.Context, storage *postgres.Storage, studentID int, password string) (bool, error) {
+	hash, err := storage.GetPasswordHash(c.Request().Context(), studentID)
+	if err != nil {
+		return false, err
+	}
+	if hash == "" {
+		return false, utils.ErrNoPassword
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil, nil
+}
+
+// UpdateProfile godoc
+// @Summary Update my profile
+// @Description Change the authenticated student's first name, last name and/or year of study. Omitted fields are left unchanged.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param profile body domain.UpdateProfileRequest true "Fields to update"
+// @Success 200 {object} domain.Student
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me [patch]
+func UpdateProfile(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		var req domain.UpdateProfileRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		student, err := storage.UpdateProfile(c.Request().Context(), userID, &req)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update profile"})
+		}
+
+		return c.JSON(http.StatusOK, student)
+	}
+}
+
+// ChangePassword godoc
+// @Summary Change my password
+// @Description Replace the password after checking the current one. Every other session is logged out; the one making the request stays. Accounts created through single sign-on have no current password and get 400 telling them to set one through password reset. Not allowed with an impersonation token.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param password body domain.ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/password [post]
+func ChangePassword(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+		sessionID, _ := c.Get("session_id").(int)
+
+		var req domain.ChangePasswordRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		valid, err := checkPassword(c, storage, userID, req.CurrentPassword)
+		if errors.Is(err, utils.ErrNoPassword) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to change password"})
+		}
+		if !valid {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "current password is incorrect"})
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to hash password"})
+		}
+
+		if err := storage.ChangePassword(c.Request().Context(), userID, string(hashedPassword), sessionID); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to change password"})
+		}
+
+		entry := auditEntry(c, domain.AuditPasswordChanged, &userID, "", "")
+		if err := storage.RecordAuditEvent(c.Request().Context(), entry); err != nil {
+			log.Printf("failed to record audit event: %v", err)
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "password changed, other sessions logged out"})
+	}
+}
+
+// DeleteAccount godoc
+// @Summary Delete my account
+// @Description Permanently delete the authenticated student together with their schedules, watchlist, notifications, petitions, sessions and other data. Study groups they own pass to the member who joined first, and are deleted only when no other member has joined. Audit log entries are kept without the email. Requires the current password; accounts created through single sign-on have none and must set one through password reset first. Admin accounts must be demoted first. Not allowed with an impersonation token.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param password body domain.DeleteAccountRequest true "Current password"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me [delete]
+func DeleteAccount(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		var req domain.DeleteAccountRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		// The role in the token may predate a promotion
+		student, err := storage.GetStudentByID(c.Request().Context(), userID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to delete account"})
+		}
+		if student.Role == domain.RoleAdmin {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "admin accounts must be demoted before deletion"})
+		}
+
+		valid, err := checkPassword(c, storage, userID, req.Password)
+		if errors.Is(err, utils.ErrNoPassword) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to delete account"})
+		}
+		if !valid {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "password is incorrect"})
+		}
+
+		err = storage.DeleteStudent(c.Request().Context(), userID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to delete account"})
+		}
+
+		// The student row is gone, so the entry names the account by id only
+		entry := auditEntry(c, domain.AuditAccountDeleted, nil, "", fmt.Sprintf("student id %d", userID))
+		if err := storage.RecordAuditEvent(c.Request().Context(), entry); err != nil {
+			log.Printf("failed to record audit event: %v", err)
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "account deleted"})
+	}
+}
+
+// ExportAccount godoc
+// @Summary Export my data
+// @Description Return everything stored about the authenticated student as one JSON document read from a single snapshot: profile, schedules with their sections, busy blocks, reviews and share links, watchlist, notifications, petitions, study group memberships and invitations, all sessions including revoked ones, linked single sign-on accounts and audit log entries. Secrets are left out: the password hash, TOTP secret, recovery codes and token hashes. Not allowed with an impersonation token.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} domain.AccountExport
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/export [get]
+func ExportAccount(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		export, err := storage.ExportStudentData(c.Request().Context(), userID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to export data"})
+		}
+
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="scheduler-export-%d.json"`, userID))
+		return c.JSON(http.StatusOK, export)
+	}
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"scheduler/internal/domain"
+	"scheduler/internal/middleware"
+	"scheduler/internal/repository/postgres"
+	"slices"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+)
+
+func SetupRoomRoutes(e *echo.Echo, storage *postgres.Storage, authMiddleware echo.MiddlewareFunc) {
+	e.GET("/api/rooms", GetRooms(storage))
+	e.GET("/api/rooms/free", GetFreeRooms(storage))
+	e.GET("/api/rooms/:id/schedule", GetRoomSchedule(storage))
+
+	// Catalog feeds may update rooms with an import:write API key
+	keyed := e.Group("/api/admin", middleware.APIKeyAuth(storage, authMiddleware))
+	keyed.PUT("/rooms/:id", UpdateRoom(storage), middleware.RequireScope(domain.ScopeImportWrite, domain.RoleAdmin))
+}
+
+// GetRooms godoc
+// @Summary Get all rooms
+// @Description List rooms with capacity and features, optionally filtered by building
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param building query string false "Building code (e.g. 'C3')"
+// @Success 200 {array} domain.Room
+// @Failure 500 {object} map[string]string
+// @Router /rooms [get]
+func GetRooms(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		rooms, err := storage.GetRooms(c.Request().Context(), c.QueryParam("building"))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch rooms"})
+		}
+
+		return c.JSON(http.StatusOK, rooms)
+	}
+}
+
+// GetFreeRooms godoc
+// @Summary Find empty rooms
+// @Description List rooms with no class meeting overlapping the given day and time range
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param day query string true "Day of week (e.g. 'Monday')"
+// @Param start query string true "Start time, HH:MM"
+// @Param end query string true "End time, HH:MM"
+// @Param min_capacity query int false "Minimum room capacity"
+// @Success 200 {array} domain.Room
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /rooms/free [get]
+func GetFreeRooms(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		day := c.QueryParam("day")
+		if !slices.Contains(domain.Days, day) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid day"})
+		}
+
+		start, err := domain.ParseClock(c.QueryParam("start"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid start time"})
+		}
+		end, err := domain.ParseClock(c.QueryParam("end"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid end time"})
+		}
+		if end <= start {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "end must be after start"})
+		}
+
+		minCapacity := 0
+		if raw := c.QueryParam("min_capacity"); raw != "" {
+			if minCapacity, err = strconv.Atoi(raw); err != nil || minCapacity < 0 {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid min_capacity"})
+			}
+		}
+
+		rooms, err := storage.GetFreeRooms(c.Request().Context(), day, domain.FormatClock(start), domain.FormatClock(end), minCapacity)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch rooms"})
+		}
+
+		return c.JSON(http.StatusOK, rooms)
+	}
+}
+
+// GetRoomSchedule godoc
+// @Summary Get a room's weekly occupancy
+// @Description Get a room with every class meeting held in it, ordered by day and time
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param id path int true "Room ID"
+// @Success 200 {object} domain.RoomSchedule
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /rooms/{id}/schedule [get]
+func GetRoomSchedule(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid room id"})
+		}
+
+		room, err := storage.GetRoomByID(c.Request().Context(), id)
+		if err != nil {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "room not found"})
+		}
+
+		bookings, err := storage.GetRoomBookings(c.Request().Context(), id)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch room schedule"})
+		}
+
+		return c.JSON(http.StatusOK, domain.RoomSchedule{Room: *room, Bookings: bookings})
+	}
+}
+
+// UpdateRoom godoc
+// @Summary Update a room
+// @Description Set a room's capacity and/or features; omitted fields are left unchanged (admins, or API keys with import:write)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path int true "Room ID"
+// @Param room body domain.UpdateRoomRequest true "Room details"
+// @Success 200 {object} domain.Room
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/rooms/{id} [put]
+func UpdateRoom(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid room id"})
+		}
+
+		var req domain.UpdateRoomRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		room, err := storage.UpdateRoom(c.Request().Context(), id, &req)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "room not found"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update room"})
+		}
+
+		return c.JSON(http.StatusOK, room)
+	}
+}
@@ -1,11 +1,14 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"scheduler/internal/domain"
+	"scheduler/internal/middleware"
 	"scheduler/internal/repository/postgres"
 	"scheduler/internal/utils"
 	"strconv"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 )
@@ -15,10 +18,21 @@ func SetupScheduleRoutes(e *echo.Echo, storage *postgres.Storage, authMiddleware
 
 	g.GET("", GetMySchedules(storage))
 	g.POST("", CreateSchedule(storage))
-	g.GET("/:id", GetScheduleByID(storage))
-	g.PATCH("/:id/submit", SubmitSchedule(storage))
-	g.POST("/:id/sections", AddSectionToSchedule(storage))
-	g.DELETE("/:id/sections/:sectionId", RemoveSectionFromSchedule(storage))
+	g.GET("/compare", CompareSchedules(storage))
+
+	owned := g.Group("/:id", middleware.ScheduleOwner(storage))
+
+	owned.GET("", GetScheduleByID(storage))
+	owned.PATCH("", UpdateSchedule(storage))
+	owned.DELETE("", DeleteSchedule(storage))
+	owned.PATCH("/submit", SubmitSchedule(storage), middleware.BlockImpersonation(), middleware.RequireVerifiedEmail(storage))
+	owned.PATCH("/withdraw", WithdrawSchedule(storage))
+	owned.POST("/clone", CloneSchedule(storage))
+	owned.POST("/sections", AddSectionToSchedule(storage))
+	owned.DELETE("/sections/:sectionId", RemoveSectionFromSchedule(storage))
+	owned.GET("/reviews", GetScheduleReviews(storage))
+	owned.GET("/analysis", GetScheduleAnalysis(storage))
+	owned.GET("/validate", ValidateSchedule(storage))
 }
 
 // GetMySchedules godoc
@@ -96,32 +110,95 @@ func CreateSchedule(storage *postgres.Storage) echo.HandlerFunc {
 // @Router /schedules/{id} [get]
 func GetScheduleByID(storage *postgres.Storage) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		studentID, ok := c.Get("user_id").(int)
+		schedule, ok := c.Get("schedule").(*domain.ScheduleWithSections)
 		if !ok {
-			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "invalid schedule context"})
 		}
 
-		scheduleID, err := strconv.Atoi(c.Param("id"))
-		if err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid schedule id"})
+		return c.JSON(http.StatusOK, schedule)
+	}
+}
+
+// UpdateSchedule godoc
+// @Summary Rename or re-describe a schedule
+// @Description Update the name and/or description of a schedule. Omitted fields are left unchanged; a null description clears it.
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Param schedule body domain.UpdateScheduleRequest true "Fields to update"
+// @Success 200 {object} domain.Schedule
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /schedules/{id} [patch]
+func UpdateSchedule(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		schedule, ok := c.Get("schedule").(*domain.ScheduleWithSections)
+		if !ok {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "invalid schedule context"})
 		}
 
-		schedule, err := storage.GetScheduleWithSections(c.Request().Context(), scheduleID)
+		var req domain.UpdateScheduleRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		updated, err := storage.UpdateSchedule(c.Request().Context(), schedule.ID, &req)
 		if err != nil {
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "schedule not found"})
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update schedule"})
+		}
+
+		return c.JSON(http.StatusOK, updated)
+	}
+}
+
+// DeleteSchedule godoc
+// @Summary Delete a schedule
+// @Description Delete a draft schedule and all its sections. Submitted, approved or enrolled schedules must be withdrawn first.
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /schedules/{id} [delete]
+func DeleteSchedule(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		schedule, ok := c.Get("schedule").(*domain.ScheduleWithSections)
+		if !ok {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "invalid schedule context"})
 		}
 
-		if schedule.StudentID != studentID {
-			return c.JSON(http.StatusForbidden, map[string]string{"error": "access denied"})
+		if !schedule.IsEditable() {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "cannot delete a schedule while " + schedule.Status})
 		}
 
-		return c.JSON(http.StatusOK, schedule)
+		err := storage.DeleteSchedule(c.Request().Context(), schedule.ID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to delete schedule"})
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "schedule deleted"})
 	}
 }
 
 // SubmitSchedule godoc
 // @Summary Submit a schedule
-// @Description Mark a schedule as submitted (finalized)
+// @Description Submit a draft (or changes-requested) schedule for advisor approval. Fails if the credit total is outside the student's limit or the student has not verified their email. Not allowed with an impersonation token.
 // @Tags schedules
 // @Accept json
 // @Produce json
@@ -132,30 +209,30 @@ func GetScheduleByID(storage *postgres.Storage) echo.HandlerFunc {
 // @Failure 401 {object} map[string]string
 // @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} domain.ValidationResult
 // @Failure 500 {object} map[string]string
 // @Router /schedules/{id}/submit [patch]
 func SubmitSchedule(storage *postgres.Storage) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		studentID, ok := c.Get("user_id").(int)
+		schedule, ok := c.Get("schedule").(*domain.ScheduleWithSections)
 		if !ok {
-			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "invalid schedule context"})
 		}
 
-		scheduleID, err := strconv.Atoi(c.Param("id"))
+		violations, err := checkCreditLoad(c.Request().Context(), storage, schedule.StudentID, schedule.ID, schedule.TotalCredits, true)
 		if err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid schedule id"})
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to check credit load"})
 		}
 
-		schedule, err := storage.GetScheduleWithSections(c.Request().Context(), scheduleID)
-		if err != nil {
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "schedule not found"})
+		if len(violations) > 0 {
+			return c.JSON(http.StatusUnprocessableEntity, domain.ValidationResult{IsValid: false, Errors: violations})
 		}
 
-		if schedule.StudentID != studentID {
-			return c.JSON(http.StatusForbidden, map[string]string{"error": "access denied"})
+		err = storage.UpdateScheduleStatus(c.Request().Context(), schedule.ID, domain.ScheduleSubmitted)
+		if errors.Is(err, utils.ErrInvalidTransition) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "schedule cannot be submitted from status " + schedule.Status})
 		}
-
-		err = storage.SubmitSchedule(c.Request().Context(), scheduleID)
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to submit schedule"})
 		}
@@ -164,6 +241,41 @@ func SubmitSchedule(storage *postgres.Storage) echo.HandlerFunc {
 	}
 }
 
+// WithdrawSchedule godoc
+// @Summary Withdraw a submitted schedule
+// @Description Return a submitted or approved schedule to draft so it can be edited again. Enrolled schedules cannot be withdrawn.
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /schedules/{id}/withdraw [patch]
+func WithdrawSchedule(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		schedule, ok := c.Get("schedule").(*domain.ScheduleWithSections)
+		if !ok {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "invalid schedule context"})
+		}
+
+		err := storage.UpdateScheduleStatus(c.Request().Context(), schedule.ID, domain.ScheduleDraft)
+		if errors.Is(err, utils.ErrInvalidTransition) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "schedule cannot be withdrawn from status " + schedule.Status})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to withdraw schedule"})
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "schedule withdrawn"})
+	}
+}
+
 // AddSectionToSchedule godoc
 // @Summary Add a section to schedule
 // @Description Add a course section to an existing schedule
@@ -178,18 +290,15 @@ func SubmitSchedule(storage *postgres.Storage) echo.HandlerFunc {
 // @Failure 401 {object} map[string]string
 // @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} domain.ValidationResult
 // @Failure 500 {object} map[string]string
 // @Router /schedules/{id}/sections [post]
 func AddSectionToSchedule(storage *postgres.Storage) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		studentID, ok := c.Get("user_id").(int)
+		schedule, ok := c.Get("schedule").(*domain.ScheduleWithSections)
 		if !ok {
-			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
-		}
-
-		scheduleID, err := strconv.Atoi(c.Param("id"))
-		if err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid schedule id"})
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "invalid schedule context"})
 		}
 
 		var req domain.AddSectionRequest
@@ -197,23 +306,56 @@ func AddSectionToSchedule(storage *postgres.Storage) echo.HandlerFunc {
 			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
 		}
 
-		schedule, err := storage.GetScheduleWithSections(c.Request().Context(), scheduleID)
-
-		if err != nil {
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "schedule not found"})
+		if ok, err := addSection(c, storage, schedule, req.SectionID, req.MeetingID); !ok {
+			return err
 		}
 
-		if schedule.StudentID != studentID {
-			return c.JSON(http.StatusForbidden, map[string]string{"error": "access denied"})
-		}
+		return c.JSON(http.StatusOK, map[string]string{"message": "section added"})
+	}
+}
 
-		err = storage.AddSectionToSchedule(c.Request().Context(), scheduleID, req.SectionID, req.MeetingID)
-		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to add section"})
-		}
+// addSection adds a section to an editable schedule within the student's
+// credit limit, writing the error response itself when it returns false
+func addSection(c echo.Context, storage *postgres.Storage, schedule *domain.ScheduleWithSections, sectionID int, meetingID *int) (bool, error) {
+	if ok, err := checkAddSection(c, storage, schedule, sectionID); !ok {
+		return false, err
+	}
 
-		return c.JSON(http.StatusOK, map[string]string{"message": "section added"})
+	err := storage.AddSectionToSchedule(c.Request().Context(), schedule.ID, sectionID, meetingID)
+	if err != nil {
+		return false, c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to add section"})
+	}
+
+	return true, nil
+}
+
+// checkAddSection runs the checks of addSection without adding the section,
+// for callers that write it as part of a larger change
+func checkAddSection(c echo.Context, storage *postgres.Storage, schedule *domain.ScheduleWithSections, sectionID int) (bool, error) {
+	if !schedule.IsEditable() {
+		return false, c.JSON(http.StatusConflict, map[string]string{"error": "schedule is locked while " + schedule.Status})
+	}
+
+	course, err := storage.GetCourseForSection(c.Request().Context(), sectionID)
+	if err != nil {
+		return false, c.JSON(http.StatusNotFound, map[string]string{"error": "section not found"})
+	}
+
+	totalCredits := schedule.TotalCredits
+	if !scheduleHasCourse(schedule, course.ID) {
+		totalCredits += course.Credits
+	}
+
+	violations, err := checkCreditLoad(c.Request().Context(), storage, schedule.StudentID, schedule.ID, totalCredits, false)
+	if err != nil {
+		return false, c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to check credit load"})
+	}
+
+	if len(violations) > 0 {
+		return false, c.JSON(http.StatusUnprocessableEntity, domain.ValidationResult{IsValid: false, Errors: violations})
 	}
+
+	return true, nil
 }
 
 // RemoveSectionFromSchedule godoc
@@ -230,18 +372,18 @@ func AddSectionToSchedule(storage *postgres.Storage) echo.HandlerFunc {
 // @Failure 401 {object} map[string]string
 // @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /schedules/{id}/sections/{sectionId} [delete]
 func RemoveSectionFromSchedule(storage *postgres.Storage) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		studentID, ok := c.Get("user_id").(int)
+		schedule, ok := c.Get("schedule").(*domain.ScheduleWithSections)
 		if !ok {
-			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "invalid schedule context"})
 		}
 
-		scheduleID, err := strconv.Atoi(c.Param("id"))
-		if err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid schedule id"})
+		if !schedule.IsEditable() {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "schedule is locked while " + schedule.Status})
 		}
 
 		sectionID, err := strconv.Atoi(c.Param("sectionId"))
@@ -249,20 +391,216 @@ func RemoveSectionFromSchedule(storage *postgres.Storage) echo.HandlerFunc {
 			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid section id"})
 		}
 
-		schedule, err := storage.GetScheduleWithSections(c.Request().Context(), scheduleID)
+		err = storage.RemoveSectionFromSchedule(c.Request().Context(), schedule.ID, sectionID)
 		if err != nil {
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "schedule not found"})
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to remove section"})
 		}
 
-		if schedule.StudentID != studentID {
-			return c.JSON(http.StatusForbidden, map[string]string{"error": "access denied"})
+		return c.JSON(http.StatusOK, map[string]string{"message": "section removed"})
+	}
+}
+
+// GetScheduleReviews godoc
+// @Summary Get advisor reviews for a schedule
+// @Description List advisor decisions and comments on a schedule, newest first
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Success 200 {array} domain.ScheduleReview
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /schedules/{id}/reviews [get]
+func GetScheduleReviews(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		schedule, ok := c.Get("schedule").(*domain.ScheduleWithSections)
+		if !ok {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "invalid schedule context"})
 		}
 
-		err = storage.RemoveSectionFromSchedule(c.Request().Context(), scheduleID, sectionID)
+		reviews, err := storage.GetScheduleReviews(c.Request().Context(), schedule.ID)
 		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to remove section"})
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch reviews"})
 		}
 
-		return c.JSON(http.StatusOK, map[string]string{"message": "section removed"})
+		return c.JSON(http.StatusOK, reviews)
+	}
+}
+
+// CloneSchedule godoc
+// @Summary Clone a schedule
+// @Description Copy a schedule with all its sections (including chosen meetings) and busy blocks into a new draft schedule
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Param clone body domain.CloneScheduleRequest false "Name for the copy (defaults to '<name> (copy)')"
+// @Success 201 {object} domain.Schedule
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /schedules/{id}/clone [post]
+func CloneSchedule(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		schedule, ok := c.Get("schedule").(*domain.ScheduleWithSections)
+		if !ok {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "invalid schedule context"})
+		}
+
+		var req domain.CloneScheduleRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		name := schedule.ScheduleName + " (copy)"
+		if req.ScheduleName != nil {
+			name = *req.ScheduleName
+		}
+
+		clone, err := storage.CloneSchedule(c.Request().Context(), schedule.ID, schedule.StudentID, name)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to clone schedule"})
+		}
+
+		return c.JSON(http.StatusCreated, clone)
+	}
+}
+
+// CompareSchedules godoc
+// @Summary Compare schedules side by side
+// @Description Diff two or more of the student's schedules: sections unique to each, shared sections, credits and conflicts per schedule
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param ids query string true "Comma-separated schedule IDs (e.g. '1,2,3')"
+// @Success 200 {object} domain.ScheduleComparison
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /schedules/compare [get]
+func CompareSchedules(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var ids []int
+		for _, raw := range strings.Split(c.QueryParam("ids"), ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(raw))
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid schedule id"})
+			}
+			ids = append(ids, id)
+		}
+
+		if len(ids) < 2 || len(ids) > 10 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "compare between 2 and 10 schedules"})
+		}
+
+		schedules := make([]domain.ScheduleWithSections, 0, len(ids))
+		for _, id := range ids {
+			schedule, err := storage.GetScheduleWithSections(c.Request().Context(), id)
+			if err != nil {
+				return c.JSON(http.StatusNotFound, map[string]string{"error": "schedule not found"})
+			}
+
+			if !middleware.OwnsSchedule(c, schedule) {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "access denied"})
+			}
+
+			schedules = append(schedules, *schedule)
+		}
+
+		return c.JSON(http.StatusOK, domain.CompareSchedules(schedules))
+	}
+}
+
+// GetScheduleAnalysis godoc
+// @Summary Analyze gaps and free periods in a schedule
+// @Description Per-day first/last class, class hours, idle gaps and longest continuous block, plus free days and short gaps between classes
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Param tight_minutes query int false "Longest break flagged as tight (default 10)"
+// @Success 200 {object} domain.ScheduleAnalysis
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /schedules/{id}/analysis [get]
+func GetScheduleAnalysis(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		schedule, ok := c.Get("schedule").(*domain.ScheduleWithSections)
+		if !ok {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "invalid schedule context"})
+		}
+
+		tightMinutes := domain.DefaultTightGapMinutes
+		if raw := c.QueryParam("tight_minutes"); raw != "" {
+			var err error
+			if tightMinutes, err = strconv.Atoi(raw); err != nil || tightMinutes < 0 {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid tight_minutes"})
+			}
+		}
+
+		return c.JSON(http.StatusOK, domain.AnalyzeSchedule(*schedule, tightMinutes))
+	}
+}
+
+// ValidateSchedule godoc
+// @Summary Validate a schedule
+// @Description Check a schedule for time conflicts and credit-load problems, and warn about back-to-back meetings in buildings too far apart for the break
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Success 200 {object} domain.ScheduleValidation
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /schedules/{id}/validate [get]
+func ValidateSchedule(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		schedule, ok := c.Get("schedule").(*domain.ScheduleWithSections)
+		if !ok {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "invalid schedule context"})
+		}
+
+		creditErrors, err := checkCreditLoad(c.Request().Context(), storage, schedule.StudentID, schedule.ID, schedule.TotalCredits, true)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to check credit load"})
+		}
+
+		matrix, err := storage.GetWalkingMatrix(c.Request().Context())
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch walking times"})
+		}
+
+		slots := schedule.Slots()
+		conflicts := domain.FindConflicts(slots)
+		if creditErrors == nil {
+			creditErrors = []domain.ValidationError{}
+		}
+
+		return c.JSON(http.StatusOK, domain.ScheduleValidation{
+			IsValid:          len(conflicts) == 0 && len(creditErrors) == 0,
+			Conflicts:        conflicts,
+			Errors:           creditErrors,
+			TransferWarnings: domain.TightTransfers(slots, matrix),
+		})
 	}
 }
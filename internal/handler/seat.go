@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"scheduler/internal/domain"
+	"scheduler/internal/middleware"
+	"scheduler/internal/realtime"
+	"scheduler/internal/repository/postgres"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxStreamedSections caps how many sections one stream may follow
+const maxStreamedSections = 100
+
+func SetupSeatRoutes(e *echo.Echo, storage *postgres.Storage, broadcaster *realtime.SeatBroadcaster) {
+	e.GET("/api/sections/seats", GetSeatCounts(storage))
+	// Streams are public and stay open, so each client IP may hold only a few
+	e.GET("/api/sections/seats/stream", StreamSeatCounts(storage, broadcaster), middleware.ConcurrencyLimit("SEAT_STREAM", 5))
+}
+
+// parseSectionIDs reads the comma-separated ids query param
+func parseSectionIDs(c echo.Context) ([]int, error) {
+	var ids []int
+	for _, raw := range strings.Split(c.QueryParam("ids"), ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("invalid section id")
+		}
+		ids = append(ids, id)
+	}
+
+	if len(ids) > maxStreamedSections {
+		return nil, fmt.Errorf("at most %d sections", maxStreamedSections)
+	}
+
+	return ids, nil
+}
+
+// GetSeatCounts godoc
+// @Summary Get seat counts for sections
+// @Description Current available and total seats for a set of sections
+// @Tags sections
+// @Accept json
+// @Produce json
+// @Param ids query string true "Comma-separated section IDs (at most 100)"
+// @Success 200 {array} domain.SeatUpdate
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /sections/seats [get]
+func GetSeatCounts(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ids, err := parseSectionIDs(c)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		counts, err := storage.GetSeatCounts(c.Request().Context(), ids)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch seat counts"})
+		}
+
+		return c.JSON(http.StatusOK, counts)
+	}
+}
+
+// StreamSeatCounts godoc
+// @Summary Stream live seat counts
+// @Description Server-Sent Events stream of seat counts. A "seats" event carries a domain.SeatUpdate: one per section on connect, then one per change. The server closes streams that fall behind; EventSource reconnects and receives a fresh snapshot. Each client IP may hold at most 5 streams at once.
+// @Tags sections
+// @Produce text/event-stream
+// @Param ids query string true "Comma-separated section IDs (at most 100)"
+// @Success 200 {object} domain.SeatUpdate
+// @Failure 400 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /sections/seats/stream [get]
+func StreamSeatCounts(storage *postgres.Storage, broadcaster *realtime.SeatBroadcaster) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ids, err := parseSectionIDs(c)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		// Subscribe before reading the snapshot so no change is missed in between
+		sub := broadcaster.Subscribe(ids)
+		defer broadcaster.Unsubscribe(sub)
+
+		snapshot, err := storage.GetSeatCounts(c.Request().Context(), ids)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch seat counts"})
+		}
+
+		res := c.Response()
+		res.Header().Set(echo.HeaderContentType, "text/event-stream")
+		res.Header().Set(echo.HeaderCacheControl, "no-cache")
+		res.Header().Set(echo.HeaderConnection, "keep-alive")
+		res.Header().Set("X-Accel-Buffering", "no")
+		res.WriteHeader(http.StatusOK)
+
+		for _, update := range snapshot {
+			if err := writeSeatEvent(res, update); err != nil {
+				return nil
+			}
+		}
+		res.Flush()
+
+		heartbeat := time.NewTicker(25 * time.Second)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-c.Request().Context().Done():
+				return nil
+			case update, ok := <-sub.C:
+				if !ok {
+					return nil
+				}
+				if err := writeSeatEvent(res, update); err != nil {
+					return nil
+				}
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(res, ": keep-alive\n\n"); err != nil {
+					return nil
+				}
+			}
+			res.Flush()
+		}
+	}
+}
+
+func writeSeatEvent(res *echo.Response, update domain.SeatUpdate) error {
+	data, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(res, "event: seats\ndata: %s\n\n", data)
+	return err
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"scheduler/internal/middleware"
+	"scheduler/internal/repository/postgres"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+)
+
+func SetupSessionRoutes(e *echo.Echo, storage *postgres.Storage, authMiddleware echo.MiddlewareFunc) {
+	g := e.Group("/api/users/me/sessions", authMiddleware)
+
+	g.GET("", GetMySessions(storage))
+	g.DELETE("", RevokeOtherSessions(storage), middleware.BlockImpersonation())
+	g.DELETE("/:sessionId", RevokeSession(storage), middleware.BlockImpersonation())
+}
+
+// GetMySessions godoc
+// @Summary List my active sessions
+// @Description List active logins with device, IP, creation and last use. The session making the request is marked current.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} domain.Session
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/sessions [get]
+func GetMySessions(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+		currentID, _ := c.Get("session_id").(int)
+
+		sessions, err := storage.GetActiveSessions(c.Request().Context(), userID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch sessions"})
+		}
+
+		for i := range sessions {
+			sessions[i].Current = sessions[i].ID == currentID
+		}
+
+		return c.JSON(http.StatusOK, sessions)
+	}
+}
+
+// RevokeSession godoc
+// @Summary Revoke a session
+// @Description Log out one session, e.g. a forgotten lab computer. Revoking the current session is the same as logging out. Not allowed with an impersonation token.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param sessionId path int true "Session ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/sessions/{sessionId} [delete]
+func RevokeSession(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		sessionID, err := strconv.Atoi(c.Param("sessionId"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid session id"})
+		}
+
+		err = storage.RevokeStudentSession(c.Request().Context(), userID, sessionID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "session not found"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to revoke session"})
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "session revoked"})
+	}
+}
+
+// RevokeOtherSessions godoc
+// @Summary Revoke all other sessions
+// @Description Log out every session except the one making the request. Not allowed with an impersonation token.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]int64
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/sessions [delete]
+func RevokeOtherSessions(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+		currentID, _ := c.Get("session_id").(int)
+
+		revoked, err := storage.RevokeOtherSessions(c.Request().Context(), userID, currentID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to revoke sessions"})
+		}
+
+		return c.JSON(http.StatusOK, map[string]int64{"revoked": revoked})
+	}
+}
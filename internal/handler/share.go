@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"scheduler/internal/domain"
+	"scheduler/internal/middleware"
+	"scheduler/internal/repository/postgres"
+	"scheduler/internal/utils"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+)
+
+func SetupShareRoutes(e *echo.Echo, storage *postgres.Storage, authMiddleware echo.MiddlewareFunc) {
+	// Public: share links are opened without logging in
+	e.GET("/api/shared/:token", GetSharedSchedule(storage))
+
+	g := e.Group("/api/schedules/:id", authMiddleware, middleware.ScheduleOwner(storage))
+
+	g.POST("/share", CreateScheduleShare(storage), middleware.BlockImpersonation())
+	g.GET("/shares", GetScheduleShares(storage))
+	g.DELETE("/shares/:shareId", RevokeScheduleShare(storage))
+}
+
+// CreateScheduleShare godoc
+// @Summary Create a share link for a schedule
+// @Description Generate a public, read-only, revocable link to the schedule. Viewers see only the chosen display name, never the student's details. Not allowed with an impersonation token.
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Param share body domain.CreateShareRequest true "Share details"
+// @Success 201 {object} domain.ScheduleShare
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /schedules/{id}/share [post]
+func CreateScheduleShare(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		schedule, ok := c.Get("schedule").(*domain.ScheduleWithSections)
+		if !ok {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "invalid schedule context"})
+		}
+
+		var req domain.CreateShareRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		var expiresAt *time.Time
+		if req.ExpiresInHours != nil {
+			t := time.Now().Add(time.Duration(*req.ExpiresInHours) * time.Hour)
+			expiresAt = &t
+		}
+
+		token, err := utils.GenerateRandomToken(24)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to generate token"})
+		}
+
+		share, err := storage.CreateScheduleShare(c.Request().Context(), schedule.ID, token, req.DisplayName, expiresAt)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create share link"})
+		}
+
+		return c.JSON(http.StatusCreated, share)
+	}
+}
+
+// GetScheduleShares godoc
+// @Summary List share links for a schedule
+// @Description List all share links created for a schedule, including revoked and expired ones
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Success 200 {array} domain.ScheduleShare
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /schedules/{id}/shares [get]
+func GetScheduleShares(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		schedule, ok := c.Get("schedule").(*domain.ScheduleWithSections)
+		if !ok {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "invalid schedule context"})
+		}
+
+		shares, err := storage.GetScheduleShares(c.Request().Context(), schedule.ID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch share links"})
+		}
+
+		return c.JSON(http.StatusOK, shares)
+	}
+}
+
+// RevokeScheduleShare godoc
+// @Summary Revoke a share link
+// @Description Permanently disable a share link
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Param shareId path int true "Share link ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /schedules/{id}/shares/{shareId} [delete]
+func RevokeScheduleShare(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		schedule, ok := c.Get("schedule").(*domain.ScheduleWithSections)
+		if !ok {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "invalid schedule context"})
+		}
+
+		shareID, err := strconv.Atoi(c.Param("shareId"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid share id"})
+		}
+
+		err = storage.RevokeScheduleShare(c.Request().Context(), schedule.ID, shareID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "share link not found"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to revoke share link"})
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "share link revoked"})
+	}
+}
+
+// GetSharedSchedule godoc
+// @Summary View a shared schedule
+// @Description Open a schedule through its public share link. No authentication required.
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Param token path string true "Share token"
+// @Success 200 {object} domain.SharedSchedule
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /shared/{token} [get]
+func GetSharedSchedule(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		share, err := storage.GetShareByToken(c.Request().Context(), c.Param("token"))
+		if err != nil || !share.IsActive(time.Now()) {
+			// Revoked and expired links are indistinguishable from unknown ones
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "share link not found"})
+		}
+
+		schedule, err := storage.GetScheduleWithSections(c.Request().Context(), share.ScheduleID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch schedule"})
+		}
+
+		return c.JSON(http.StatusOK, domain.SharedSchedule{
+			DisplayName:  share.DisplayName,
+			ScheduleName: schedule.ScheduleName,
+			Description:  schedule.Description,
+			TotalCredits: schedule.TotalCredits,
+			Sections:     schedule.Sections,
+			ExpiresAt:    share.ExpiresAt,
+		})
+	}
+}
@@ -2,36 +2,50 @@ package handler
 
 import (
 	"context"
+	"log"
+	"math"
 	"net/http"
 	"scheduler/internal/domain"
+	"scheduler/internal/mailer"
+	"scheduler/internal/middleware"
 	"scheduler/internal/repository/postgres"
 	"scheduler/internal/utils"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func SetupStudentRoutes(e *echo.Echo, storage *postgres.Storage, authMiddleware echo.MiddlewareFunc) {
-	e.POST("/api/auth/register", Register(storage))
-	e.POST("/api/auth/login", Login(storage))
+func SetupStudentRoutes(e *echo.Echo, storage *postgres.Storage, m mailer.Mailer, authMiddleware echo.MiddlewareFunc) {
+	e.POST("/api/auth/register", Register(storage, m), middleware.RateLimit("REGISTER", 5, 5))
+	e.POST("/api/auth/login", Login(storage, utils.LoginPolicyFromEnv()), middleware.RateLimit("LOGIN", 20, 10))
+	e.POST("/api/auth/refresh", RefreshToken(storage))
+	e.POST("/api/auth/logout", Logout(storage), authMiddleware)
 
 	e.GET("/api/users/me", GetCurrentStudent(storage), authMiddleware)
 }
 
+// dummyPasswordHash is compared against when the email matches no account,
+// so unknown and known emails take the same time to reject
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+
 // Login godoc
 // @Summary Login student
-// @Description Authenticate student and return JWT token
+// @Description Authenticate student and return JWT token. Unknown emails and wrong passwords get the same response; repeated failures lock the account email and the client IP for a growing period. Accounts with two-factor get 202 and a challenge to finish at /auth/login/2fa instead.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param credentials body domain.LoginRequest true "Login credentials"
 // @Success 200 {object} domain.AuthResponse
+// @Success 202 {object} domain.TwoFactorChallenge
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /auth/login [post]
-func Login(storage *postgres.Storage) echo.HandlerFunc {
+func Login(storage *postgres.Storage, policy utils.LoginPolicy) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		var req domain.LoginRequest
 
@@ -39,46 +53,89 @@ func Login(storage *postgres.Storage) echo.HandlerFunc {
 			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
 		}
 
-		student, err := storage.GetStudentByEmail(context.Background(), req.Email)
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
 
+		ctx := c.Request().Context()
+		ip := c.RealIP()
+
+		accountKey, ipKey := postgres.LoginThrottleKeys(req.Email, ip)
+		lockout, err := storage.GetLoginLockout(ctx, accountKey, ipKey)
 		if err != nil {
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "no student found with such email"})
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to log in"})
+		}
+		if lockout > 0 {
+			if err := storage.RecordAuditEvent(ctx, auditEntry(c, domain.AuditLoginLocked, nil, req.Email, "")); err != nil {
+				log.Printf("failed to record audit event: %v", err)
+			}
+			return tooManyLoginAttempts(c, lockout)
 		}
 
-		err = bcrypt.CompareHashAndPassword([]byte(student.PasswordHash), []byte(req.Password))
+		student, err := storage.GetStudentByEmail(ctx, req.Email)
+		found := err == nil
+		hash, detail := dummyPasswordHash, "unknown email"
+		var studentID *int
+		if found {
+			hash, detail, studentID = []byte(student.PasswordHash), "wrong password", &student.ID
+		}
 
-		if err != nil {
-			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "wrong password"})
+		if bcrypt.CompareHashAndPassword(hash, []byte(req.Password)) != nil || !found {
+			entry := auditEntry(c, domain.AuditLoginFailed, studentID, req.Email, detail)
+			lockout, err := storage.RecordLoginFailure(ctx, req.Email, ip, policy, entry)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to log in"})
+			}
+			if lockout > 0 {
+				return tooManyLoginAttempts(c, lockout)
+			}
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": utils.ErrInvalidCredentials.Error()})
+		}
+
+		// With a second factor the account counter stays until that passes
+		// too, so each correct password does not reset the guessing of codes
+		if student.TwoFactorEnabled || twoFactorRequired(student) {
+			challenge, err := startChallenge(c, storage, student)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to log in"})
+			}
+			return c.JSON(http.StatusAccepted, challenge)
+		}
+
+		if err := storage.ClearLoginFailures(ctx, req.Email); err != nil {
+			log.Printf("failed to clear login failures: %v", err)
 		}
 
-		token, err := utils.GenerateToken(student.ID, student.Email)
+		response, err := startSession(c, storage, student)
 
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to generate token"})
 		}
 
-		response := domain.AuthResponse{
-			Token:   token,
-			Student: *student,
-		}
-
 		return c.JSON(http.StatusOK, response)
 	}
 }
 
+func tooManyLoginAttempts(c echo.Context, lockout time.Duration) error {
+	c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(lockout.Seconds()))))
+	return c.JSON(http.StatusTooManyRequests, map[string]string{"error": "too many failed login attempts, try again later"})
+}
+
 // Register godoc
 // @Summary Register new student
-// @Description Create a new student account
+// @Description Create a new student account and email a verification link
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param student body domain.RegisterRequest true "Student registration details"
 // @Success 201 {object} domain.AuthResponse
+// @Success 202 {object} domain.TwoFactorChallenge
 // @Failure 400 {object} map[string]string
 // @Failure 409 {object} map[string]string
+// @Failure 429 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /auth/register [post]
-func Register(storage *postgres.Storage) echo.HandlerFunc {
+func Register(storage *postgres.Storage, m mailer.Mailer) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		var req domain.RegisterRequest
 
@@ -121,15 +178,22 @@ func Register(storage *postgres.Storage) echo.HandlerFunc {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		}
 
-		token, err := utils.GenerateToken(student.ID, student.Email)
+		// The account works without verification, so the mail need not hold
+		// up the response
+		sendAccountTokenLater(c, storage, m, student, domain.TokenVerifyEmail)
 
-		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to generate token"})
+		if twoFactorRequired(student) {
+			challenge, err := startChallenge(c, storage, student)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to generate token"})
+			}
+			return c.JSON(http.StatusAccepted, challenge)
 		}
 
-		response := domain.AuthResponse{
-			Token:   token,
-			Student: *student,
+		response, err := startSession(c, storage, student)
+
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to generate token"})
 		}
 
 		return c.JSON(http.StatusCreated, response)
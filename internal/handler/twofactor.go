@@ -0,0 +1,502 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"scheduler/internal/domain"
+	"scheduler/internal/middleware"
+	"scheduler/internal/qrcode"
+	"scheduler/internal/repository/postgres"
+	"scheduler/internal/utils"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	challengeTTL         = 10 * time.Minute
+	challengeMaxAttempts = 5
+	recoveryCodeCount    = 10
+)
+
+func SetupTwoFactorRoutes(e *echo.Echo, storage *postgres.Storage, authMiddleware echo.MiddlewareFunc) {
+	limit := middleware.RateLimit("TWO_FACTOR", 10, 5)
+	e.POST("/api/auth/login/2fa", CompleteTwoFactorLogin(storage, utils.LoginPolicyFromEnv()), limit)
+	e.POST("/api/auth/login/2fa/enroll", EnrollTwoFactorAtLogin(storage), limit)
+
+	g := e.Group("/api/users/me/2fa", authMiddleware, middleware.BlockImpersonation())
+	g.POST("/enroll", EnrollTwoFactor(storage))
+	g.POST("/confirm", ConfirmTwoFactor(storage))
+	g.POST("/recovery-codes", RegenerateRecoveryCodes(storage))
+	g.POST("/disable", DisableTwoFactor(storage))
+}
+
+// twoFactorRequired reports whether the student may not log in without a
+// second factor, either individually or because of their role
+func twoFactorRequired(student *domain.Student) bool {
+	return student.TwoFactorRequired || utils.RoleRequiresTwoFactor(student.Role)
+}
+
+// startChallenge issues a login challenge in place of a session
+func startChallenge(c echo.Context, storage *postgres.Storage, student *domain.Student) (*domain.TwoFactorChallenge, error) {
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(challengeTTL)
+	if err := storage.CreateLoginChallenge(c.Request().Context(), student.ID, utils.HashToken(token), expiresAt); err != nil {
+		return nil, err
+	}
+
+	return &domain.TwoFactorChallenge{
+		ChallengeToken: token,
+		ExpiresAt:      expiresAt,
+		SetupRequired:  !student.TwoFactorEnabled,
+	}, nil
+}
+
+// enrollTOTP stores a new pending secret for the student and returns what
+// their authenticator app needs
+func enrollTOTP(ctx context.Context, storage *postgres.Storage, student *domain.Student) (*domain.TwoFactorEnrollment, error) {
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	uri := utils.TOTPURI(student.Email, secret)
+	png, err := qrcode.PNG(uri, 6)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := storage.SetPendingTOTPSecret(ctx, student.ID, secret); err != nil {
+		return nil, err
+	}
+
+	return &domain.TwoFactorEnrollment{
+		Secret:     secret,
+		OTPAuthURI: uri,
+		QRCode:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// newRecoveryCodes returns a fresh set of recovery codes and their hashes
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	codes, err = utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, code := range codes {
+		hashes = append(hashes, utils.HashToken(utils.NormalizeRecoveryCode(code)))
+	}
+	return codes, hashes, nil
+}
+
+// verifyTOTP checks a code from the student's enabled authenticator and
+// makes sure it cannot be used again
+func verifyTOTP(ctx context.Context, storage *postgres.Storage, studentID int, secret, code string) (bool, error) {
+	step, ok := utils.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	return storage.UseTOTPStep(ctx, studentID, step)
+}
+
+// EnrollTwoFactor godoc
+// @Summary Start two-factor enrollment
+// @Description Generate a TOTP secret and return it as an otpauth URI and QR code PNG. Two-factor is enabled only after a first code is confirmed. Not allowed with an impersonation token.
+// @Tags two-factor
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} domain.TwoFactorEnrollment
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/2fa/enroll [post]
+func EnrollTwoFactor(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		student, err := storage.GetStudentByID(c.Request().Context(), userID)
+		if err != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		enrollment, err := enrollTOTP(c.Request().Context(), storage, student)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "two-factor is already enabled"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to start enrollment"})
+		}
+
+		return c.JSON(http.StatusOK, enrollment)
+	}
+}
+
+// ConfirmTwoFactor godoc
+// @Summary Confirm two-factor enrollment
+// @Description Enable two-factor with a first code from the authenticator app. The response lists recovery codes, which are shown only this once. Not allowed with an impersonation token.
+// @Tags two-factor
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code body domain.TwoFactorCodeRequest true "Authenticator code"
+// @Success 200 {object} domain.RecoveryCodesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/2fa/confirm [post]
+func ConfirmTwoFactor(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		var req domain.TwoFactorCodeRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		ctx := c.Request().Context()
+		secret, enabled, err := storage.GetTOTPSecret(ctx, userID)
+		if err != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+		if enabled {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "two-factor is already enabled"})
+		}
+		if secret == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "start enrollment first"})
+		}
+
+		step, valid := utils.ValidateTOTP(secret, req.Code, time.Now())
+		if !valid {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid code"})
+		}
+
+		codes, hashes, err := newRecoveryCodes()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to enable two-factor"})
+		}
+
+		if err := storage.EnableTOTP(ctx, userID, step, hashes); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to enable two-factor"})
+		}
+
+		if err := storage.RecordAuditEvent(ctx, auditEntry(c, domain.AuditTwoFactorEnabled, &userID, "", "")); err != nil {
+			log.Printf("failed to record audit event: %v", err)
+		}
+
+		return c.JSON(http.StatusOK, domain.RecoveryCodesResponse{RecoveryCodes: codes})
+	}
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Replace recovery codes
+// @Description Issue a new set of recovery codes; the previous ones stop working. Requires a current authenticator code. Not allowed with an impersonation token.
+// @Tags two-factor
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code body domain.TwoFactorCodeRequest true "Authenticator code"
+// @Success 200 {object} domain.RecoveryCodesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/2fa/recovery-codes [post]
+func RegenerateRecoveryCodes(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		var req domain.TwoFactorCodeRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		ctx := c.Request().Context()
+		secret, enabled, err := storage.GetTOTPSecret(ctx, userID)
+		if err != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+		if !enabled {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "two-factor is not enabled"})
+		}
+
+		valid, err := verifyTOTP(ctx, storage, userID, secret, req.Code)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to check code"})
+		}
+		if !valid {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid code"})
+		}
+
+		codes, hashes, err := newRecoveryCodes()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to generate recovery codes"})
+		}
+
+		if err := storage.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to generate recovery codes"})
+		}
+
+		return c.JSON(http.StatusOK, domain.RecoveryCodesResponse{RecoveryCodes: codes})
+	}
+}
+
+// DisableTwoFactor godoc
+// @Summary Turn off two-factor
+// @Description Remove the authenticator and recovery codes. Requires a current authenticator code and is refused when two-factor is mandatory for the account. Not allowed with an impersonation token.
+// @Tags two-factor
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code body domain.TwoFactorCodeRequest true "Authenticator code"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/2fa/disable [post]
+func DisableTwoFactor(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		var req domain.TwoFactorCodeRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		ctx := c.Request().Context()
+		student, err := storage.GetStudentByID(ctx, userID)
+		if err != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+		if twoFactorRequired(student) {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "two-factor is required for this account"})
+		}
+
+		secret, enabled, err := storage.GetTOTPSecret(ctx, userID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to disable two-factor"})
+		}
+		if !enabled {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "two-factor is not enabled"})
+		}
+
+		valid, err := verifyTOTP(ctx, storage, userID, secret, req.Code)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to check code"})
+		}
+		if !valid {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid code"})
+		}
+
+		if err := storage.DisableTOTP(ctx, userID); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to disable two-factor"})
+		}
+
+		if err := storage.RecordAuditEvent(ctx, auditEntry(c, domain.AuditTwoFactorDisabled, &userID, "", "")); err != nil {
+			log.Printf("failed to record audit event: %v", err)
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "two-factor disabled"})
+	}
+}
+
+// EnrollTwoFactorAtLogin godoc
+// @Summary Enroll two-factor during login
+// @Description For accounts where two-factor is mandatory but not set up yet: start enrollment with the login challenge token. Finish the login by sending the first code to /auth/login/2fa.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param challenge body domain.ChallengeRequest true "Login challenge"
+// @Success 200 {object} domain.TwoFactorEnrollment
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/login/2fa/enroll [post]
+func EnrollTwoFactorAtLogin(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req domain.ChallengeRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		ctx := c.Request().Context()
+		studentID, err := storage.UseLoginChallenge(ctx, utils.HashToken(req.ChallengeToken), challengeMaxAttempts)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid or expired challenge"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to check challenge"})
+		}
+
+		student, err := storage.GetStudentByID(ctx, studentID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to start enrollment"})
+		}
+
+		enrollment, err := enrollTOTP(ctx, storage, student)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "two-factor is already enabled"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to start enrollment"})
+		}
+
+		return c.JSON(http.StatusOK, enrollment)
+	}
+}
+
+// CompleteTwoFactorLogin godoc
+// @Summary Finish a two-factor login
+// @Description Exchange a login challenge and an authenticator or recovery code for tokens. If the login also finished enrollment, the response includes the new recovery codes. A challenge allows five attempts, and wrong codes also count towards the account's failed login lockout.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param login body domain.TwoFactorLoginRequest true "Challenge and code"
+// @Success 200 {object} domain.AuthResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/login/2fa [post]
+func CompleteTwoFactorLogin(storage *postgres.Storage, policy utils.LoginPolicy) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req domain.TwoFactorLoginRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		ctx := c.Request().Context()
+		challengeHash := utils.HashToken(req.ChallengeToken)
+		studentID, err := storage.UseLoginChallenge(ctx, challengeHash, challengeMaxAttempts)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid or expired challenge"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to check challenge"})
+		}
+
+		student, err := storage.GetStudentByID(ctx, studentID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to log in"})
+		}
+
+		// Wrong codes lock the account like wrong passwords, across challenges
+		accountKey, ipKey := postgres.LoginThrottleKeys(student.Email, c.RealIP())
+		lockout, err := storage.GetLoginLockout(ctx, accountKey, ipKey)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to log in"})
+		}
+		if lockout > 0 {
+			return tooManyLoginAttempts(c, lockout)
+		}
+
+		secret, enabled, err := storage.GetTOTPSecret(ctx, studentID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to log in"})
+		}
+
+		var valid bool
+		var recoveryCodes []string
+		switch {
+		case req.RecoveryCode != "":
+			if enabled {
+				valid, err = storage.UseRecoveryCode(ctx, studentID, utils.HashToken(utils.NormalizeRecoveryCode(req.RecoveryCode)))
+			}
+		case enabled:
+			valid, err = verifyTOTP(ctx, storage, studentID, secret, req.Code)
+		case secret == "":
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "start enrollment first"})
+		default:
+			// The first code of a mandatory enrollment also enables it
+			step, ok := utils.ValidateTOTP(secret, req.Code, time.Now())
+			if ok {
+				var hashes []string
+				recoveryCodes, hashes, err = newRecoveryCodes()
+				if err == nil {
+					err = storage.EnableTOTP(ctx, studentID, step, hashes)
+				}
+				valid = err == nil
+			}
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to check code"})
+		}
+
+		if !valid {
+			entry := auditEntry(c, domain.AuditTwoFactorFailed, &studentID, student.Email, "")
+			lockout, err := storage.RecordLoginFailure(ctx, student.Email, c.RealIP(), policy, entry)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to check code"})
+			}
+			if lockout > 0 {
+				return tooManyLoginAttempts(c, lockout)
+			}
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid code"})
+		}
+
+		if err := storage.CompleteLoginChallenge(ctx, challengeHash); err != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid or expired challenge"})
+		}
+
+		if err := storage.ClearLoginFailures(ctx, student.Email); err != nil {
+			log.Printf("failed to clear login failures: %v", err)
+		}
+
+		student.TwoFactorEnabled = true
+		response, err := startSession(c, storage, student)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to generate token"})
+		}
+		response.RecoveryCodes = recoveryCodes
+
+		return c.JSON(http.StatusOK, response)
+	}
+}
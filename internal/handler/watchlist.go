@@ -0,0 +1,308 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"scheduler/internal/domain"
+	"scheduler/internal/middleware"
+	"scheduler/internal/repository/postgres"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+)
+
+func SetupWatchlistRoutes(e *echo.Echo, storage *postgres.Storage, authMiddleware echo.MiddlewareFunc) {
+	g := e.Group("/api/watchlist", authMiddleware)
+
+	g.GET("", GetWatchlist(storage))
+	g.POST("", AddToWatchlist(storage))
+	g.PATCH("/:itemId", UpdateWatchlistItem(storage))
+	g.DELETE("/:itemId", RemoveFromWatchlist(storage))
+	g.POST("/:itemId/move", MoveWatchlistItem(storage))
+}
+
+// loadOwnedSchedule loads a schedule the current user owns, writing the
+// error response itself when it returns nil
+func loadOwnedSchedule(c echo.Context, storage *postgres.Storage, scheduleID int) (*domain.ScheduleWithSections, error) {
+	schedule, err := storage.GetScheduleWithSections(c.Request().Context(), scheduleID)
+	if err != nil {
+		return nil, c.JSON(http.StatusNotFound, map[string]string{"error": "schedule not found"})
+	}
+
+	if !middleware.OwnsSchedule(c, schedule) {
+		return nil, c.JSON(http.StatusForbidden, map[string]string{"error": "access denied"})
+	}
+
+	return schedule, nil
+}
+
+// GetWatchlist godoc
+// @Summary Get my watchlist
+// @Description List watched sections and courses with live seat counts. With schedule_id, each section is marked as already in that schedule and/or conflicting with it.
+// @Tags watchlist
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param schedule_id query int false "Schedule to check conflicts against"
+// @Success 200 {array} domain.WatchlistEntry
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /watchlist [get]
+func GetWatchlist(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		var schedule *domain.ScheduleWithSections
+		if raw := c.QueryParam("schedule_id"); raw != "" {
+			scheduleID, err := strconv.Atoi(raw)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid schedule id"})
+			}
+
+			if schedule, err = loadOwnedSchedule(c, storage, scheduleID); schedule == nil {
+				return err
+			}
+		}
+
+		entries, err := storage.GetWatchlist(c.Request().Context(), userID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch watchlist"})
+		}
+
+		if schedule != nil {
+			for i := range entries {
+				entries[i].CompareToSchedule(schedule)
+			}
+		}
+
+		return c.JSON(http.StatusOK, entries)
+	}
+}
+
+// AddToWatchlist godoc
+// @Summary Watch a section or course
+// @Description Bookmark a single section or a whole course, with an optional note. Watching an item again updates its note.
+// @Tags watchlist
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param item body domain.WatchRequest true "Exactly one of section_id or course_id"
+// @Success 201 {object} domain.WatchlistEntry
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /watchlist [post]
+func AddToWatchlist(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		var req domain.WatchRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		if req.SectionID != nil {
+			if _, err := storage.GetCourseForSection(c.Request().Context(), *req.SectionID); err != nil {
+				return c.JSON(http.StatusNotFound, map[string]string{"error": "section not found"})
+			}
+		} else {
+			if _, err := storage.GetCourseByID(c.Request().Context(), *req.CourseID); err != nil {
+				return c.JSON(http.StatusNotFound, map[string]string{"error": "course not found"})
+			}
+		}
+
+		item, err := storage.AddToWatchlist(c.Request().Context(), userID, &req)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to add to watchlist"})
+		}
+
+		entry, err := storage.GetWatchlistEntry(c.Request().Context(), *item)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch watched sections"})
+		}
+
+		return c.JSON(http.StatusCreated, entry)
+	}
+}
+
+// UpdateWatchlistItem godoc
+// @Summary Update a watchlist note
+// @Description Set or clear the note on a watched item
+// @Tags watchlist
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param itemId path int true "Watchlist item ID"
+// @Param note body domain.UpdateWatchRequest true "Note"
+// @Success 200 {object} domain.WatchlistItem
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /watchlist/{itemId} [patch]
+func UpdateWatchlistItem(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		itemID, err := strconv.Atoi(c.Param("itemId"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid item id"})
+		}
+
+		var req domain.UpdateWatchRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		item, err := storage.UpdateWatchlistNote(c.Request().Context(), userID, itemID, req.Note)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "watchlist item not found"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update watchlist item"})
+		}
+
+		return c.JSON(http.StatusOK, item)
+	}
+}
+
+// RemoveFromWatchlist godoc
+// @Summary Stop watching an item
+// @Description Remove a section or course from the watchlist
+// @Tags watchlist
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param itemId path int true "Watchlist item ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /watchlist/{itemId} [delete]
+func RemoveFromWatchlist(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		itemID, err := strconv.Atoi(c.Param("itemId"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid item id"})
+		}
+
+		err = storage.RemoveFromWatchlist(c.Request().Context(), userID, itemID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "watchlist item not found"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to remove watchlist item"})
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "watchlist item removed"})
+	}
+}
+
+// MoveWatchlistItem godoc
+// @Summary Move a watched section into a schedule
+// @Description Add a watched section (or, for a watched course, one of its sections) to a schedule with the same checks as adding it directly, then drop it from the watchlist unless keep is set. Both happen together or not at all.
+// @Tags watchlist
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param itemId path int true "Watchlist item ID"
+// @Param move body domain.MoveWatchRequest true "Target schedule"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} domain.ValidationResult
+// @Failure 500 {object} map[string]string
+// @Router /watchlist/{itemId}/move [post]
+func MoveWatchlistItem(storage *postgres.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID, ok := c.Get("user_id").(int)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+		}
+
+		itemID, err := strconv.Atoi(c.Param("itemId"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid item id"})
+		}
+
+		var req domain.MoveWatchRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		if err := c.Validate(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		item, err := storage.GetWatchlistItem(c.Request().Context(), userID, itemID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "watchlist item not found"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch watchlist item"})
+		}
+
+		sectionID := item.SectionID
+		if item.CourseID != nil {
+			if req.SectionID == nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "section_id is required for a watched course"})
+			}
+
+			course, err := storage.GetCourseForSection(c.Request().Context(), *req.SectionID)
+			if err != nil || course.ID != *item.CourseID {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "section is not part of the watched course"})
+			}
+			sectionID = req.SectionID
+		}
+
+		schedule, err := loadOwnedSchedule(c, storage, req.ScheduleID)
+		if schedule == nil {
+			return err
+		}
+
+		if ok, err := checkAddSection(c, storage, schedule, *sectionID); !ok {
+			return err
+		}
+
+		err = storage.MoveWatchlistItem(c.Request().Context(), userID, itemID, schedule.ID, *sectionID, req.MeetingID, req.Keep)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "watchlist item not found"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to move section"})
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "section added"})
+	}
+}
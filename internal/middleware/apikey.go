@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"scheduler/internal/domain"
+	"scheduler/internal/repository/postgres"
+	"scheduler/internal/utils"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// APIKeyAuth accepts an API key sent as X-API-Key or as "Authorization:
+// Bearer sk_..." and stores it in the context as "api_key". Requests without
+// a key are handed to fallback, usually JWTAuth. Routes using it must also
+// use RequireScope, which is what stops a key reaching user endpoints.
+func APIKeyAuth(storage *postgres.Storage, fallback echo.MiddlewareFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withUser := fallback(next)
+
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get("X-API-Key")
+			if key == "" {
+				bearer, ok := strings.CutPrefix(c.Request().Header.Get("Authorization"), "Bearer ")
+				if !ok || !strings.HasPrefix(bearer, utils.APIKeyPrefix) {
+					return withUser(c)
+				}
+				key = bearer
+			}
+
+			apiKey, err := storage.UseAPIKey(c.Request().Context(), utils.HashToken(key), c.RealIP())
+			if errors.Is(err, pgx.ErrNoRows) {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": utils.ErrInvalidAPIKey.Error()})
+			}
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to check api key"})
+			}
+
+			c.Set("api_key", apiKey)
+
+			return next(c)
+		}
+	}
+}
+
+// RequireScope lets API key requests through only if the key has scope, and
+// user requests only if the role set by JWTAuth is one of roles. It must be
+// registered after APIKeyAuth.
+func RequireScope(scope string, roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		byRole := RequireRole(roles...)(next)
+
+		return func(c echo.Context) error {
+			apiKey, ok := c.Get("api_key").(*domain.APIKey)
+			if !ok {
+				return byRole(c)
+			}
+
+			if !slices.Contains(apiKey.Scopes, scope) {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "api key lacks scope " + scope})
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -1,14 +1,20 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
+	"scheduler/internal/domain"
+	"scheduler/internal/repository/postgres"
 	"scheduler/internal/utils"
 	"strings"
 
 	"github.com/labstack/echo/v4"
 )
 
-func JWTAuth() echo.MiddlewareFunc {
+// JWTAuth accepts access tokens that are unexpired, name a live session and
+// whose jti has not been revoked. Requests made with an impersonation token
+// are written to the audit log before they run, and refused if that fails.
+func JWTAuth(storage *postgres.Storage) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			authHeader := c.Request().Header.Get("Authorization")
@@ -28,10 +34,48 @@ func JWTAuth() echo.MiddlewareFunc {
 				return c.JSON(http.StatusUnauthorized, map[string]string{"error": utils.ErrInvalidToken.Error()})
 			}
 
+			// Tokens issued before sessions existed cannot be revoked
+			if claims.ID == "" || claims.SessionID == 0 {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": utils.ErrInvalidToken.Error()})
+			}
+
+			revoked, err := storage.IsTokenRevoked(c.Request().Context(), claims.ID, claims.SessionID)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to check token"})
+			}
+			if revoked {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": utils.ErrInvalidToken.Error()})
+			}
+
 			c.Set("user_id", claims.UserID)
 			c.Set("email", claims.Email)
+			c.Set("role", claims.Role)
+			c.Set("session_id", claims.SessionID)
+			c.Set("claims", claims)
+
+			if claims.Actor != nil {
+				c.Set("impersonator_id", claims.Actor.UserID)
+
+				if err := recordImpersonatedRequest(c, storage, claims); err != nil {
+					return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to record impersonated request"})
+				}
+			}
 
 			return next(c)
 		}
 	}
 }
+
+func recordImpersonatedRequest(c echo.Context, storage *postgres.Storage, claims *utils.Claims) error {
+	ip, userAgent := c.RealIP(), c.Request().UserAgent()
+	detail := fmt.Sprintf("by %s (id %d): %s %s", claims.Actor.Email, claims.Actor.UserID, c.Request().Method, c.Request().RequestURI)
+
+	return storage.RecordAuditEvent(c.Request().Context(), domain.AuditEntry{
+		Event:     domain.AuditImpersonatedRequest,
+		StudentID: &claims.UserID,
+		Email:     &claims.Email,
+		IPAddress: &ip,
+		UserAgent: &userAgent,
+		Detail:    &detail,
+	})
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"scheduler/internal/domain"
+	"scheduler/internal/repository/postgres"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// GroupMember loads the study group named by the :id path param and stores
+// it in the context under "group". Only students who have joined the group
+// may proceed. It must be registered after JWTAuth.
+func GroupMember(storage *postgres.Storage) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			userID, ok := c.Get("user_id").(int)
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+			}
+
+			groupID, err := strconv.Atoi(c.Param("id"))
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid group id"})
+			}
+
+			group, err := storage.GetGroupByID(c.Request().Context(), groupID)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return c.JSON(http.StatusNotFound, map[string]string{"error": "group not found"})
+			}
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to get group"})
+			}
+
+			member, err := storage.GetGroupMember(c.Request().Context(), groupID, userID)
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to check access"})
+			}
+			if err != nil || member.Status != domain.MemberJoined {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "access denied"})
+			}
+
+			c.Set("group", group)
+
+			return next(c)
+		}
+	}
+}
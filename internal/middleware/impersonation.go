@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// BlockImpersonation refuses the request if it was made with an
+// impersonation token, for actions only the student may take themselves. It
+// must be registered after JWTAuth.
+func BlockImpersonation() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if _, impersonating := c.Get("impersonator_id").(int); impersonating {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "not allowed while impersonating"})
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"scheduler/internal/utils"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	echoMiddleware "github.com/labstack/echo/v4/middleware"
+	"golang.org/x/time/rate"
+)
+
+// RateLimit limits each client IP to perMinute requests with bursts of up to
+// burst. Both can be overridden with RATE_LIMIT_<NAME>_PER_MINUTE and
+// RATE_LIMIT_<NAME>_BURST. Counters are kept in memory, so with several
+// instances the limit applies per instance.
+func RateLimit(name string, perMinute, burst int) echo.MiddlewareFunc {
+	perMinute = utils.EnvInt("RATE_LIMIT_"+name+"_PER_MINUTE", perMinute)
+	burst = utils.EnvInt("RATE_LIMIT_"+name+"_BURST", burst)
+	retryAfter := strconv.Itoa(int(math.Ceil(60 / float64(perMinute))))
+
+	return echoMiddleware.RateLimiterWithConfig(echoMiddleware.RateLimiterConfig{
+		Store: echoMiddleware.NewRateLimiterMemoryStoreWithConfig(echoMiddleware.RateLimiterMemoryStoreConfig{
+			Rate:      rate.Limit(float64(perMinute) / 60),
+			Burst:     burst,
+			ExpiresIn: 10 * time.Minute,
+		}),
+		IdentifierExtractor: func(c echo.Context) (string, error) {
+			return c.RealIP(), nil
+		},
+		ErrorHandler: func(c echo.Context, err error) error {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "could not identify client"})
+		},
+		DenyHandler: func(c echo.Context, identifier string, err error) error {
+			c.Response().Header().Set("Retry-After", retryAfter)
+			return c.JSON(http.StatusTooManyRequests, map[string]string{"error": "too many requests, try again later"})
+		},
+	})
+}
+
+// ConcurrencyLimit allows each client IP at most limit requests in flight at
+// once, for long-lived requests such as event streams that a request rate
+// limit does not bound. The limit can be overridden with
+// RATE_LIMIT_<NAME>_CONCURRENT. Like RateLimit it counts per instance.
+func ConcurrencyLimit(name string, limit int) echo.MiddlewareFunc {
+	limit = utils.EnvInt("RATE_LIMIT_"+name+"_CONCURRENT", limit)
+
+	var mu sync.Mutex
+	active := make(map[string]int)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ip := c.RealIP()
+
+			mu.Lock()
+			if active[ip] >= limit {
+				mu.Unlock()
+				return c.JSON(http.StatusTooManyRequests, map[string]string{"error": "too many open connections, close one and try again"})
+			}
+			active[ip]++
+			mu.Unlock()
+
+			defer func() {
+				mu.Lock()
+				if active[ip]--; active[ip] == 0 {
+					delete(active, ip)
+				}
+				mu.Unlock()
+			}()
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequireRole allows the request through only if the role set by JWTAuth is
+// one of roles. It must be registered after JWTAuth.
+func RequireRole(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			role, ok := c.Get("role").(string)
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+			}
+
+			if !slices.Contains(roles, role) {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "access denied"})
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"scheduler/internal/domain"
+	"scheduler/internal/repository/postgres"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// scheduleAccessFunc decides whether the user may act on a loaded schedule
+type scheduleAccessFunc func(c echo.Context, userID int, schedule *domain.ScheduleWithSections) (bool, error)
+
+// scheduleAccess loads the schedule named by the :id path param and stores it
+// in the context under "schedule" if allowed grants access. Admins always
+// have access.
+func scheduleAccess(storage *postgres.Storage, allowed scheduleAccessFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			userID, ok := c.Get("user_id").(int)
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+			}
+
+			scheduleID, err := strconv.Atoi(c.Param("id"))
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid schedule id"})
+			}
+
+			schedule, err := storage.GetScheduleWithSections(c.Request().Context(), scheduleID)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return c.JSON(http.StatusNotFound, map[string]string{"error": "schedule not found"})
+			}
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to get schedule"})
+			}
+
+			if c.Get("role") != domain.RoleAdmin {
+				ok, err := allowed(c, userID, schedule)
+				if err != nil {
+					return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to check access"})
+				}
+				if !ok {
+					return c.JSON(http.StatusForbidden, map[string]string{"error": "access denied"})
+				}
+			}
+
+			c.Set("schedule", schedule)
+
+			return next(c)
+		}
+	}
+}
+
+// ScheduleOwner lets only the owning student (or an admin) act on the
+// schedule. It must be registered after JWTAuth.
+func ScheduleOwner(storage *postgres.Storage) echo.MiddlewareFunc {
+	return scheduleAccess(storage, func(c echo.Context, userID int, schedule *domain.ScheduleWithSections) (bool, error) {
+		return schedule.StudentID == userID, nil
+	})
+}
+
+// OwnsSchedule applies the ScheduleOwner rule to a schedule loaded by the
+// handler itself, for routes that act on several schedules at once
+func OwnsSchedule(c echo.Context, schedule *domain.ScheduleWithSections) bool {
+	userID, ok := c.Get("user_id").(int)
+	return ok && (schedule.StudentID == userID || c.Get("role") == domain.RoleAdmin)
+}
+
+// AdviseeSchedule lets only the advisor assigned to the schedule's student
+// (or an admin) act on the schedule. It must be registered after JWTAuth.
+func AdviseeSchedule(storage *postgres.Storage) echo.MiddlewareFunc {
+	return scheduleAccess(storage, func(c echo.Context, userID int, schedule *domain.ScheduleWithSections) (bool, error) {
+		return isAdvisorOf(c, storage, userID, schedule.StudentID)
+	})
+}
+
+// AdvisesStudent applies the AdviseeSchedule rule to a student named by
+// something other than a schedule, such as a petition
+func AdvisesStudent(c echo.Context, storage *postgres.Storage, studentID int) (bool, error) {
+	if c.Get("role") == domain.RoleAdmin {
+		return true, nil
+	}
+
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return false, nil
+	}
+	return isAdvisorOf(c, storage, userID, studentID)
+}
+
+func isAdvisorOf(c echo.Context, storage *postgres.Storage, advisorID, studentID int) (bool, error) {
+	student, err := storage.GetStudentByID(c.Request().Context(), studentID)
+	if err != nil {
+		return false, err
+	}
+	return student.AdvisorID != nil && *student.AdvisorID == advisorID, nil
+}
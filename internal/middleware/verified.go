@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"scheduler/internal/domain"
+	"scheduler/internal/repository/postgres"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequireVerifiedEmail allows the request through only if the current user
+// has verified their email. On schedule routes it checks the schedule's
+// owner instead, so an admin acting on the schedule cannot bypass it. It
+// must be registered after JWTAuth, and after ScheduleOwner where used.
+func RequireVerifiedEmail(storage *postgres.Storage) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			userID, ok := c.Get("user_id").(int)
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+			}
+
+			if schedule, ok := c.Get("schedule").(*domain.ScheduleWithSections); ok && schedule.StudentID != userID {
+				owner, err := storage.GetStudentByID(c.Request().Context(), schedule.StudentID)
+				if err != nil {
+					return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to check email verification"})
+				}
+				if owner.EmailVerifiedAt == nil {
+					return c.JSON(http.StatusForbidden, map[string]string{"error": "the schedule owner has not verified their email"})
+				}
+				return next(c)
+			}
+
+			user, err := storage.GetStudentByID(c.Request().Context(), userID)
+			if err != nil {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid user context"})
+			}
+
+			if user.EmailVerifiedAt == nil {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "verify your email first"})
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"scheduler/internal/domain"
+	"scheduler/internal/mailer"
+	"time"
+)
+
+// Channel delivers notifications outside the app. The in-app feed is always
+// written first, so a failed delivery is logged and not retried.
+type Channel interface {
+	Deliver(ctx context.Context, d domain.NotificationDelivery) error
+}
+
+// EmailChannel sends each notification as a plain-text email
+type EmailChannel struct {
+	Mailer mailer.Mailer
+}
+
+func (ch *EmailChannel) Deliver(ctx context.Context, d domain.NotificationDelivery) error {
+	return ch.Mailer.Send(ctx, mailer.Message{
+		To:      d.Email,
+		Subject: d.Title,
+		Body:    fmt.Sprintf("Hi %s,\n\n%s\n", d.FirstName, d.Body),
+	})
+}
+
+// WebhookChannel POSTs each notification as JSON. When Secret is set the body
+// is signed with HMAC-SHA256 in the X-Signature header.
+type WebhookChannel struct {
+	URL    string
+	Secret string
+	Client *http.Client
+}
+
+func (ch *WebhookChannel) Deliver(ctx context.Context, d domain.NotificationDelivery) error {
+	body, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ch.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if ch.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(ch.Secret))
+		mac.Write(body)
+		req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := ch.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// NoopChannel keeps notifications in the in-app feed only
+type NoopChannel struct{}
+
+func (NoopChannel) Deliver(ctx context.Context, d domain.NotificationDelivery) error {
+	return nil
+}
+
+// ChannelFromEnv picks the channel named by NOTIFY_CHANNEL: "email" (the
+// default, sent through mail), "webhook" (NOTIFY_WEBHOOK_URL and optional
+// NOTIFY_WEBHOOK_SECRET) or "none"
+func ChannelFromEnv(mail mailer.Mailer) (Channel, error) {
+	switch channel := os.Getenv("NOTIFY_CHANNEL"); channel {
+	case "", "email":
+		return &EmailChannel{Mailer: mail}, nil
+	case "webhook":
+		url := os.Getenv("NOTIFY_WEBHOOK_URL")
+		if url == "" {
+			return nil, fmt.Errorf("NOTIFY_WEBHOOK_URL not set")
+		}
+		return &WebhookChannel{
+			URL:    url,
+			Secret: os.Getenv("NOTIFY_WEBHOOK_SECRET"),
+			Client: &http.Client{Timeout: 10 * time.Second},
+		}, nil
+	case "none":
+		return NoopChannel{}, nil
+	default:
+		return nil, fmt.Errorf("unknown NOTIFY_CHANNEL %q", channel)
+	}
+}
@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"scheduler/internal/repository/postgres"
+	"scheduler/internal/utils"
+	"time"
+)
+
+// SeatAlertWorker turns queued seat openings into notifications for the
+// students watching those sections and delivers them through a Channel
+type SeatAlertWorker struct {
+	Storage  *postgres.Storage
+	Channel  Channel
+	Interval time.Duration
+	// Cooldown is how long a student waits before being alerted about the
+	// same section again
+	Cooldown  time.Duration
+	BatchSize int
+}
+
+// NewSeatAlertWorker reads SEAT_ALERT_INTERVAL_SECONDS (default 30) and
+// SEAT_ALERT_COOLDOWN_MINUTES (default 60) from the environment
+func NewSeatAlertWorker(storage *postgres.Storage, channel Channel) *SeatAlertWorker {
+	return &SeatAlertWorker{
+		Storage:   storage,
+		Channel:   channel,
+		Interval:  time.Duration(utils.EnvInt("SEAT_ALERT_INTERVAL_SECONDS", 30)) * time.Second,
+		Cooldown:  time.Duration(utils.EnvInt("SEAT_ALERT_COOLDOWN_MINUTES", 60)) * time.Minute,
+		BatchSize: 500,
+	}
+}
+
+// Run processes openings every Interval until ctx is cancelled
+func (w *SeatAlertWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.RunOnce(ctx); err != nil {
+			log.Printf("seat alerts: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *SeatAlertWorker) RunOnce(ctx context.Context) error {
+	deliveries, err := w.Storage.ProcessSeatOpenings(ctx, int(w.Cooldown.Minutes()), w.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range deliveries {
+		if err := w.Channel.Deliver(ctx, d); err != nil {
+			log.Printf("seat alerts: failed to deliver notification %d to student %d: %v", d.ID, d.StudentID, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package oidc_test
+
+import (
+	"context"
+	"scheduler/internal/oidc"
+	"scheduler/internal/oidc/oidctest"
+	"testing"
+)
+
+func newProvider(t *testing.T) (*oidc.Provider, *oidctest.Issuer) {
+	t.Helper()
+
+	issuer, err := oidctest.NewIssuer("scheduler")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(issuer.Close)
+
+	provider := oidc.NewProvider(oidc.Config{
+		IssuerURL:      issuer.URL(),
+		ClientID:       issuer.ClientID,
+		RedirectURL:    "http://localhost:8080/oidc/callback",
+		Scopes:         []string{"openid", "email", "profile"},
+		AllowedDomains: []string{"nu.edu.kz"},
+		StudentIDClaim: "student_id",
+	})
+	return provider, issuer
+}
+
+func TestExchange(t *testing.T) {
+	provider, issuer := newProvider(t)
+	ctx := context.Background()
+
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", "verifier-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, state, err := issuer.Authorize(authURL, map[string]any{
+		"sub":            "user-1",
+		"email":          "aigerim@nu.edu.kz",
+		"email_verified": "true",
+		"given_name":     "Aigerim",
+		"family_name":    "Bekova",
+		"student_id":     float64(202012345),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state != "state-1" {
+		t.Fatalf("state = %q, want state-1", state)
+	}
+
+	identity, err := provider.Exchange(ctx, code, "verifier-1", "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if identity.Issuer != issuer.URL() || identity.Subject != "user-1" {
+		t.Errorf("identity = %s %s, want %s user-1", identity.Issuer, identity.Subject, issuer.URL())
+	}
+	if identity.Email != "aigerim@nu.edu.kz" || !identity.EmailVerified {
+		t.Errorf("email = %q verified %v", identity.Email, identity.EmailVerified)
+	}
+	if identity.GivenName != "Aigerim" || identity.FamilyName != "Bekova" {
+		t.Errorf("name = %q %q", identity.GivenName, identity.FamilyName)
+	}
+	if identity.StudentID != "202012345" {
+		t.Errorf("student ID = %q, want 202012345", identity.StudentID)
+	}
+}
+
+func TestExchangeRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		verifier string
+		nonce    string
+	}{
+		{"wrong code verifier", "other-verifier", "nonce-1"},
+		{"nonce mismatch", "verifier-1", "other-nonce"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, issuer := newProvider(t)
+			ctx := context.Background()
+
+			authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", "verifier-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			code, _, err := issuer.Authorize(authURL, map[string]any{"sub": "user-1", "email": "a@nu.edu.kz"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := provider.Exchange(ctx, code, tt.verifier, tt.nonce); err == nil {
+				t.Fatal("Exchange succeeded")
+			}
+		})
+	}
+}
+
+func TestAllowsEmail(t *testing.T) {
+	provider, _ := newProvider(t)
+
+	tests := []struct {
+		email string
+		want  bool
+	}{
+		{"student@nu.edu.kz", true},
+		{"Student@NU.EDU.KZ", true},
+		{"student@gmail.com", false},
+		{"student@evil-nu.edu.kz", false},
+		{"no-at-sign", false},
+	}
+
+	for _, tt := range tests {
+		if got := provider.AllowsEmail(tt.email); got != tt.want {
+			t.Errorf("AllowsEmail(%q) = %v, want %v", tt.email, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// The worked example from the "HELLO WORLD" 1-M symbol found in most QR
+// code tutorials
+func TestRSRemainder(t *testing.T) {
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	if got := rsRemainder(data, rsDivisor(10)); !bytes.Equal(got, want) {
+		t.Errorf("rsRemainder = %v, want %v", got, want)
+	}
+}
+
+// Format information for level M from ISO/IEC 18004 table C.1
+var formatBits = []int{
+	0b101010000010010,
+	0b101000100100101,
+	0b101111001111100,
+	0b101101101001011,
+	0b100010111111001,
+	0b100000011001110,
+	0b100111110010111,
+	0b100101010100000,
+}
+
+func TestFormatBits(t *testing.T) {
+	for mask, want := range formatBits {
+		m := newMatrix(1)
+		m.drawFormatBits(mask)
+
+		if got := readFormatBits(m.modules); got != want {
+			t.Errorf("mask %d: format bits = %015b, want %015b", mask, got, want)
+		}
+	}
+}
+
+// Version information from ISO/IEC 18004 table D.1
+func TestVersionBits(t *testing.T) {
+	want := map[int]int{7: 0x07C94, 8: 0x085BC, 9: 0x09A99, 10: 0x0A4D3}
+
+	for ver, bits := range want {
+		m := newMatrix(ver)
+		m.drawFunctionPatterns(ver)
+
+		var topRight, bottomLeft int
+		for i := 17; i >= 0; i-- {
+			a, b := m.size-11+i%3, i/3
+			topRight = topRight<<1 | bit(m.modules[b][a])
+			bottomLeft = bottomLeft<<1 | bit(m.modules[a][b])
+		}
+		if topRight != bits || bottomLeft != bits {
+			t.Errorf("version %d: bits = %05X and %05X, want %05X", ver, topRight, bottomLeft, bits)
+		}
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	tests := []struct {
+		text    string
+		version int
+	}{
+		{"", 1},
+		{"a", 1},
+		{strings.Repeat("x", 14), 1},
+		{strings.Repeat("x", 15), 2},
+		{"otpauth://totp/Scheduler:student@nu.edu.kz?secret=JBSWY3DPEHPK3PXP&issuer=Scheduler", 5},
+		{strings.Repeat("é", 42), 5},
+		{strings.Repeat("é", 42) + "!", 6},
+		{strings.Repeat("0123456789", 15), 8},
+		{strings.Repeat("0123456789", 16), 9},
+		{strings.Repeat("z", 213), 10},
+	}
+
+	for _, tt := range tests {
+		code, err := Encode(tt.text)
+		if err != nil {
+			t.Errorf("Encode(%d bytes): %v", len(tt.text), err)
+			continue
+		}
+
+		if got := (code.Size - 17) / 4; got != tt.version {
+			t.Errorf("Encode(%d bytes) chose version %d, want %d", len(tt.text), got, tt.version)
+		}
+
+		got, err := decode(code)
+		if err != nil {
+			t.Errorf("decode(Encode(%d bytes)): %v", len(tt.text), err)
+			continue
+		}
+		if got != tt.text {
+			t.Errorf("decode(Encode(%q)) = %q", tt.text, got)
+		}
+	}
+}
+
+func TestEncodeTooLong(t *testing.T) {
+	if _, err := Encode(strings.Repeat("z", 214)); !errors.Is(err, ErrTooLong) {
+		t.Errorf("err = %v, want ErrTooLong", err)
+	}
+}
+
+func TestPNG(t *testing.T) {
+	const scale = 3
+
+	data, err := PNG("otpauth://totp/Scheduler:a@nu.edu.kz?secret=JBSWY3DPEHPK3PXP", scale)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, _ := Encode("otpauth://totp/Scheduler:a@nu.edu.kz?secret=JBSWY3DPEHPK3PXP")
+	side := (code.Size + 8) * scale
+	if b := img.Bounds(); b.Dx() != side || b.Dy() != side {
+		t.Fatalf("image is %dx%d, want %dx%d", b.Dx(), b.Dy(), side, side)
+	}
+
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			r, _, _, _ := img.At((x+4)*scale+1, (y+4)*scale+1).RGBA()
+			if dark := r == 0; dark != code.Modules[y][x] {
+				t.Fatalf("pixel for module (%d, %d) dark = %v", x, y, dark)
+			}
+		}
+	}
+}
+
+func bit(dark bool) int {
+	if dark {
+		return 1
+	}
+	return 0
+}
+
+// readFormatBits reads the copy of the format information around the top
+// left finder, most significant bit first
+func readFormatBits(modules [][]bool) int {
+	var bits int
+	for x := 0; x <= 5; x++ {
+		bits = bits<<1 | bit(modules[8][x])
+	}
+	bits = bits<<1 | bit(modules[8][7])
+	bits = bits<<1 | bit(modules[8][8])
+	bits = bits<<1 | bit(modules[7][8])
+	for y := 5; y >= 0; y-- {
+		bits = bits<<1 | bit(modules[y][8])
+	}
+	return bits
+}
+
+var masks = []func(row, col int) bool{
+	func(i, j int) bool { return (i+j)%2 == 0 },
+	func(i, j int) bool { return i%2 == 0 },
+	func(i, j int) bool { return j%3 == 0 },
+	func(i, j int) bool { return (i+j)%3 == 0 },
+	func(i, j int) bool { return (i/2+j/3)%2 == 0 },
+	func(i, j int) bool { return i*j%2+i*j%3 == 0 },
+	func(i, j int) bool { return (i*j%2+i*j%3)%2 == 0 },
+	func(i, j int) bool { return ((i+j)%2+i*j%3)%2 == 0 },
+}
+
+// decode reads a symbol back the way a scanner would once it has sampled
+// the modules: check the finder patterns, find the mask from the format
+// information, read the codewords, verify every block's Reed-Solomon
+// syndromes and parse the byte mode segment
+func decode(code *Code) (string, error) {
+	ver := (code.Size - 17) / 4
+	if ver < 1 || ver >= len(versions) || code.Size != ver*4+17 {
+		return "", errors.New("bad size")
+	}
+	modules := code.Modules
+
+	for _, corner := range [][2]int{{0, 0}, {code.Size - 7, 0}, {0, code.Size - 7}} {
+		for dy := 0; dy < 7; dy++ {
+			for dx := 0; dx < 7; dx++ {
+				ring := max(abs(dx-3), abs(dy-3))
+				if modules[corner[1]+dy][corner[0]+dx] != (ring != 2) {
+					return "", errors.New("broken finder pattern")
+				}
+			}
+		}
+	}
+
+	format := readFormatBits(modules)
+	mask := -1
+	for i, bits := range formatBits {
+		if bits == format {
+			mask = i
+		}
+	}
+	if mask < 0 {
+		return "", errors.New("format information is not level M")
+	}
+
+	// Which modules carry data depends only on the version
+	layout := newMatrix(ver)
+	layout.drawFunctionPatterns(ver)
+
+	v := versions[ver]
+	total := v.dataCodewords() + v.ecPerBlock*len(v.blocks)
+	codewords := make([]byte, total)
+	n := 0
+	upward := true
+	for right := code.Size - 1; right >= 1; right, upward = right-2, !upward {
+		if right == 6 {
+			right--
+		}
+		for k := 0; k < code.Size; k++ {
+			y := k
+			if upward {
+				y = code.Size - 1 - k
+			}
+			for _, x := range []int{right, right - 1} {
+				if layout.isFunction[y][x] || n >= total*8 {
+					continue
+				}
+				if modules[y][x] != masks[mask](y, x) {
+					codewords[n/8] |= 1 << (7 - n%8)
+				}
+				n++
+			}
+		}
+	}
+	if n != total*8 {
+		return "", errors.New("symbol too small for its codewords")
+	}
+
+	blocks := make([][]byte, len(v.blocks))
+	i := 0
+	for k := 0; k < v.blocks[len(v.blocks)-1]; k++ {
+		for b, size := range v.blocks {
+			if k < size {
+				blocks[b] = append(blocks[b], codewords[i])
+				i++
+			}
+		}
+	}
+	for k := 0; k < v.ecPerBlock; k++ {
+		for b := range blocks {
+			blocks[b] = append(blocks[b], codewords[i])
+			i++
+		}
+	}
+
+	var data []byte
+	for b, block := range blocks {
+		// A valid block is divisible by the generator, so it vanishes at
+		// each of its roots 2^0 .. 2^(ec-1)
+		root := byte(1)
+		for k := 0; k < v.ecPerBlock; k++ {
+			var sum byte
+			for _, c := range block {
+				sum = gfMultiply(sum, root) ^ c
+			}
+			if sum != 0 {
+				return "", errors.New("Reed-Solomon check failed")
+			}
+			root = gfMultiply(root, 2)
+		}
+		data = append(data, block[:v.blocks[b]]...)
+	}
+
+	if data[0]>>4 != 0b0100 {
+		return "", errors.New("not byte mode")
+	}
+	countBits := 8
+	if ver >= 10 {
+		countBits = 16
+	}
+	readBits := func(offset, n int) int {
+		value := 0
+		for k := offset; k < offset+n; k++ {
+			value = value<<1 | int(data[k/8]>>(7-k%8)&1)
+		}
+		return value
+	}
+	length := readBits(4, countBits)
+	if 4+countBits+8*length > len(data)*8 {
+		return "", errors.New("length exceeds capacity")
+	}
+
+	text := make([]byte, length)
+	for k := range text {
+		text[k] = byte(readBits(4+countBits+8*k, 8))
+	}
+	return string(text), nil
+}
@@ -0,0 +1,101 @@
+package realtime
+
+import (
+	"context"
+	"log"
+	"scheduler/internal/domain"
+	"scheduler/internal/repository/postgres"
+	"sync"
+	"time"
+)
+
+// subscriptionBuffer is how many updates a subscriber may fall behind before
+// it is dropped; a dropped client reconnects and gets a fresh snapshot
+const subscriptionBuffer = 64
+
+// SeatSubscription receives updates for a fixed set of sections. C is closed
+// when the subscriber falls too far behind or unsubscribes.
+type SeatSubscription struct {
+	C        chan domain.SeatUpdate
+	sections map[int]bool
+}
+
+// SeatBroadcaster fans seat updates out to in-process subscribers
+type SeatBroadcaster struct {
+	mu   sync.Mutex
+	subs map[*SeatSubscription]struct{}
+}
+
+func NewSeatBroadcaster() *SeatBroadcaster {
+	return &SeatBroadcaster{subs: make(map[*SeatSubscription]struct{})}
+}
+
+func (b *SeatBroadcaster) Subscribe(sectionIDs []int) *SeatSubscription {
+	sub := &SeatSubscription{
+		C:        make(chan domain.SeatUpdate, subscriptionBuffer),
+		sections: make(map[int]bool, len(sectionIDs)),
+	}
+	for _, id := range sectionIDs {
+		sub.sections[id] = true
+	}
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+func (b *SeatBroadcaster) Unsubscribe(sub *SeatSubscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(sub)
+}
+
+// remove must be called with mu held
+func (b *SeatBroadcaster) remove(sub *SeatSubscription) {
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.C)
+	}
+}
+
+func (b *SeatBroadcaster) Publish(update domain.SeatUpdate) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs {
+		if !sub.sections[update.SectionID] {
+			continue
+		}
+		select {
+		case sub.C <- update:
+		default:
+			b.remove(sub)
+		}
+	}
+}
+
+// RunSeatListener feeds database seat changes into the broadcaster until ctx
+// is cancelled, reconnecting with backoff when the listening connection drops
+func RunSeatListener(ctx context.Context, storage *postgres.Storage, broadcaster *SeatBroadcaster) {
+	backoff := time.Second
+	for {
+		started := time.Now()
+		err := storage.ListenSeatChanges(ctx, broadcaster.Publish)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if time.Since(started) > time.Minute {
+			backoff = time.Second
+		}
+		log.Printf("seat listener: %v, reconnecting in %s", err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, time.Minute)
+	}
+}
@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"context"
+	"scheduler/internal/domain"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateAccountToken stores a new token for purpose, retiring any earlier
+// unused token for the same purpose so only the latest link works
+func (s *Storage) CreateAccountToken(ctx context.Context, studentID int, purpose, tokenHash string, expiresAt time.Time) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	const retireQuery = `
+		UPDATE account_tokens
+		SET used_at = NOW()
+		WHERE student_id = $1 AND purpose = $2 AND used_at IS NULL;`
+
+	if _, err := tx.Exec(ctx, retireQuery, studentID, purpose); err != nil {
+		return err
+	}
+
+	const query = `
+		INSERT INTO account_tokens (student_id, purpose, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4);`
+
+	if _, err := tx.Exec(ctx, query, studentID, purpose, tokenHash, expiresAt); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// consumeAccountToken marks an unused, unexpired token as used and returns
+// its student, or pgx.ErrNoRows if there is no such token
+func consumeAccountToken(ctx context.Context, tx pgx.Tx, purpose, tokenHash string) (int, error) {
+	const query = `
+		UPDATE account_tokens
+		SET used_at = NOW()
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING student_id;`
+
+	var studentID int
+	err := tx.QueryRow(ctx, query, tokenHash, purpose).Scan(&studentID)
+	return studentID, err
+}
+
+// VerifyEmail consumes a verification token and marks the student's email as
+// verified; it returns pgx.ErrNoRows for an unknown, used or expired token
+func (s *Storage) VerifyEmail(ctx context.Context, tokenHash string) (*domain.Student, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	studentID, err := consumeAccountToken(ctx, tx, domain.TokenVerifyEmail, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+
+	const query = `
+		UPDATE students
+		SET email_verified_at = COALESCE(email_verified_at, NOW())
+		WHERE id = $1
+		RETURNING ` + studentColumns + `;`
+
+	student, err := scanStudent(tx.QueryRow(ctx, query, studentID))
+	if err != nil {
+		return nil, err
+	}
+
+	return student, tx.Commit(ctx)
+}
+
+// ResetPassword consumes a reset token, sets the new password hash and
+// revokes every session of the student. Receiving the email also proves the
+// address, so the email is marked verified. It returns pgx.ErrNoRows for an
+// unknown, used or expired token.
+func (s *Storage) ResetPassword(ctx context.Context, tokenHash, passwordHash string) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	studentID, err := consumeAccountToken(ctx, tx, domain.TokenResetPassword, tokenHash)
+	if err != nil {
+		return err
+	}
+
+	const query = `
+		UPDATE students
+		SET password_hash = $2, email_verified_at = COALESCE(email_verified_at, NOW())
+		WHERE id = $1;`
+
+	if _, err := tx.Exec(ctx, query, studentID, passwordHash); err != nil {
+		return err
+	}
+
+	if _, err := revokeOtherSessions(ctx, tx, studentID, 0); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ChangePassword sets a new password hash and revokes every other session of
+// the student, keeping the one that made the change
+func (s *Storage) ChangePassword(ctx context.Context, studentID int, passwordHash string, keepSession int) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `UPDATE students SET password_hash = $2 WHERE id = $1;`, studentID, passwordHash); err != nil {
+		return err
+	}
+
+	if _, err := revokeOtherSessions(ctx, tx, studentID, keepSession); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"scheduler/internal/domain"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const apiKeyColumns = `id, name, prefix, scopes, created_by, created_at, expires_at, last_used_at, last_used_ip, revoked_at`
+
+func scanAPIKey(row pgx.Row) (*domain.APIKey, error) {
+	var k domain.APIKey
+	err := row.Scan(
+		&k.ID,
+		&k.Name,
+		&k.Prefix,
+		&k.Scopes,
+		&k.CreatedBy,
+		&k.CreatedAt,
+		&k.ExpiresAt,
+		&k.LastUsedAt,
+		&k.LastUsedIP,
+		&k.RevokedAt,
+	)
+	return &k, err
+}
+
+func (s *Storage) CreateAPIKey(ctx context.Context, name, prefix, keyHash string, scopes []string, createdBy int, expiresAt *time.Time) (*domain.APIKey, error) {
+	const query = `
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + apiKeyColumns + `;`
+
+	return scanAPIKey(s.pool.QueryRow(ctx, query, name, prefix, keyHash, scopes, createdBy, expiresAt))
+}
+
+// GetAPIKeys lists every key, including revoked and expired ones, newest first
+func (s *Storage) GetAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	const query = `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY created_at DESC, id DESC;`
+
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []domain.APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *k)
+	}
+
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey returns pgx.ErrNoRows if the key does not exist or was
+// already revoked
+func (s *Storage) RevokeAPIKey(ctx context.Context, id int) (*domain.APIKey, error) {
+	const query = `
+		UPDATE api_keys SET revoked_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL
+		RETURNING ` + apiKeyColumns + `;`
+
+	return scanAPIKey(s.pool.QueryRow(ctx, query, id))
+}
+
+// UseAPIKey looks up a live key by hash and records that it was used from
+// ipAddress. It returns pgx.ErrNoRows for unknown, expired and revoked keys.
+func (s *Storage) UseAPIKey(ctx context.Context, keyHash, ipAddress string) (*domain.APIKey, error) {
+	const query = `
+		UPDATE api_keys SET last_used_at = NOW(), last_used_ip = NULLIF($2, '')
+		WHERE key_hash = $1
+		  AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > NOW())
+		RETURNING ` + apiKeyColumns + `;`
+
+	return scanAPIKey(s.pool.QueryRow(ctx, query, keyHash, ipAddress))
+}
@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"scheduler/internal/domain"
+)
+
+const auditColumns = `id, event, student_id, email, ip_address, user_agent, detail, created_at`
+
+// RecordAuditEvent appends an entry to the audit log
+func (s *Storage) RecordAuditEvent(ctx context.Context, entry domain.AuditEntry) error {
+	return insertAuditEntry(ctx, s.pool, entry)
+}
+
+func insertAuditEntry(ctx context.Context, db execer, entry domain.AuditEntry) error {
+	const query = `
+		INSERT INTO audit_log (event, student_id, email, ip_address, user_agent, detail)
+		VALUES ($1, $2, $3, $4, $5, $6);`
+
+	_, err := db.Exec(ctx, query, entry.Event, entry.StudentID, entry.Email, entry.IPAddress, entry.UserAgent, entry.Detail)
+	return err
+}
+
+// GetAuditLog returns the newest entries first, optionally narrowed to one
+// event type or student
+func (s *Storage) GetAuditLog(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	query := `
+		SELECT ` + auditColumns + `
+		FROM audit_log
+		WHERE ($1 = '' OR event = $1)
+		  AND ($2 = 0 OR student_id = $2)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3;`
+
+	rows, err := s.pool.Query(ctx, query, filter.Event, filter.StudentID, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []domain.AuditEntry{}
+	for rows.Next() {
+		var a domain.AuditEntry
+		err := rows.Scan(
+			&a.ID,
+			&a.Event,
+			&a.StudentID,
+			&a.Email,
+			&a.IPAddress,
+			&a.UserAgent,
+			&a.Detail,
+			&a.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, a)
+	}
+
+	return entries, rows.Err()
+}
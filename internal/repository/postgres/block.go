@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"scheduler/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const blockColumns = `id, schedule_id, label, days, start_time::text, end_time::text, location, created_at`
+
+func scanBlock(row pgx.Row) (*domain.BusyBlock, error) {
+	var b domain.BusyBlock
+	err := row.Scan(
+		&b.ID,
+		&b.ScheduleID,
+		&b.Label,
+		&b.Days,
+		&b.StartTime,
+		&b.EndTime,
+		&b.Location,
+		&b.CreatedAt,
+	)
+	return &b, err
+}
+
+func (s *Storage) GetScheduleBlocks(ctx context.Context, scheduleID int) ([]domain.BusyBlock, error) {
+	const query = `
+		SELECT ` + blockColumns + `
+		FROM schedule_blocks
+		WHERE schedule_id = $1
+		ORDER BY start_time;`
+
+	rows, err := s.pool.Query(ctx, query, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	blocks := []domain.BusyBlock{}
+	for rows.Next() {
+		b, err := scanBlock(rows)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, *b)
+	}
+
+	return blocks, rows.Err()
+}
+
+func (s *Storage) CreateScheduleBlock(ctx context.Context, scheduleID int, req *domain.BusyBlockRequest) (*domain.BusyBlock, error) {
+	const query = `
+		INSERT INTO schedule_blocks (schedule_id, label, days, start_time, end_time, location)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + blockColumns + `;`
+
+	return scanBlock(s.pool.QueryRow(ctx, query,
+		scheduleID, req.Label, req.Days, req.StartTime, req.EndTime, req.Location,
+	))
+}
+
+// UpdateScheduleBlock replaces a block; it returns pgx.ErrNoRows if the block
+// does not belong to the schedule
+func (s *Storage) UpdateScheduleBlock(ctx context.Context, scheduleID, blockID int, req *domain.BusyBlockRequest) (*domain.BusyBlock, error) {
+	const query = `
+		UPDATE schedule_blocks
+		SET label = $3, days = $4, start_time = $5, end_time = $6, location = $7
+		WHERE id = $1 AND schedule_id = $2
+		RETURNING ` + blockColumns + `;`
+
+	return scanBlock(s.pool.QueryRow(ctx, query,
+		blockID, scheduleID, req.Label, req.Days, req.StartTime, req.EndTime, req.Location,
+	))
+}
+
+// DeleteScheduleBlock returns pgx.ErrNoRows if the block does not belong to the schedule
+func (s *Storage) DeleteScheduleBlock(ctx context.Context, scheduleID, blockID int) error {
+	const query = `DELETE FROM schedule_blocks WHERE id = $1 AND schedule_id = $2;`
+
+	tag, err := s.pool.Exec(ctx, query, blockID, scheduleID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"context"
+	"scheduler/internal/domain"
+	"scheduler/internal/utils"
+)
+
+func (s *Storage) GetBuildings(ctx context.Context) ([]domain.Building, error) {
+	const query = `
+		SELECT code, name, latitude, longitude, wheelchair_access, accessibility_notes, created_at
+		FROM buildings
+		ORDER BY code;`
+
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	var buildings []domain.Building
+	for rows.Next() {
+		var b domain.Building
+		err := rows.Scan(
+			&b.Code,
+			&b.Name,
+			&b.Latitude,
+			&b.Longitude,
+			&b.WheelchairAccess,
+			&b.AccessibilityNotes,
+			&b.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		buildings = append(buildings, b)
+	}
+
+	return buildings, rows.Err()
+}
+
+// UpsertBuilding creates a building or replaces its details
+func (s *Storage) UpsertBuilding(ctx context.Context, code string, req *domain.UpdateBuildingRequest) (*domain.Building, error) {
+	const query = `
+		INSERT INTO buildings (code, name, latitude, longitude, wheelchair_access, accessibility_notes)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (code) DO UPDATE SET
+			name = EXCLUDED.name,
+			latitude = EXCLUDED.latitude,
+			longitude = EXCLUDED.longitude,
+			wheelchair_access = EXCLUDED.wheelchair_access,
+			accessibility_notes = EXCLUDED.accessibility_notes
+		RETURNING code, name, latitude, longitude, wheelchair_access, accessibility_notes, created_at;`
+
+	var b domain.Building
+	err := s.pool.QueryRow(ctx, query,
+		code, req.Name, req.Latitude, req.Longitude, req.WheelchairAccess, req.AccessibilityNotes,
+	).Scan(
+		&b.Code,
+		&b.Name,
+		&b.Latitude,
+		&b.Longitude,
+		&b.WheelchairAccess,
+		&b.AccessibilityNotes,
+		&b.CreatedAt,
+	)
+
+	return &b, err
+}
+
+func (s *Storage) GetWalkingTimes(ctx context.Context) ([]domain.WalkingTime, error) {
+	const query = `SELECT from_building, to_building, minutes FROM walking_times ORDER BY from_building, to_building;`
+
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	var times []domain.WalkingTime
+	for rows.Next() {
+		var t domain.WalkingTime
+		if err := rows.Scan(&t.FromBuilding, &t.ToBuilding, &t.Minutes); err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+
+	return times, rows.Err()
+}
+
+// SetWalkingTime stores the walking time for a pair of buildings in either order
+func (s *Storage) SetWalkingTime(ctx context.Context, req *domain.SetWalkingTimeRequest) (*domain.WalkingTime, error) {
+	const query = `
+		INSERT INTO walking_times (from_building, to_building, minutes)
+		VALUES (LEAST($1, $2), GREATEST($1, $2), $3)
+		ON CONFLICT (from_building, to_building) DO UPDATE SET minutes = EXCLUDED.minutes
+		RETURNING from_building, to_building, minutes;`
+
+	var t domain.WalkingTime
+	err := s.pool.QueryRow(ctx, query, req.FromBuilding, req.ToBuilding, req.Minutes).Scan(
+		&t.FromBuilding,
+		&t.ToBuilding,
+		&t.Minutes,
+	)
+	if isConstraintViolation(err, foreignKeyViolation, "walking_times_from_building_fkey") ||
+		isConstraintViolation(err, foreignKeyViolation, "walking_times_to_building_fkey") {
+		return nil, utils.ErrUnknownBuilding
+	}
+
+	return &t, err
+}
+
+func (s *Storage) GetWalkingMatrix(ctx context.Context) (domain.WalkingMatrix, error) {
+	buildings, err := s.GetBuildings(ctx)
+	if err != nil {
+		return domain.WalkingMatrix{}, err
+	}
+
+	times, err := s.GetWalkingTimes(ctx)
+	if err != nil {
+		return domain.WalkingMatrix{}, err
+	}
+
+	return domain.NewWalkingMatrix(buildings, times), nil
+}
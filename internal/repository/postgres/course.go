@@ -79,20 +79,47 @@ func (s *Storage) GetCourseByID(ctx context.Context, id int) (*domain.Course, er
 	return &c, err
 }
 
-func (s *Storage) GetSectionsForCourse(ctx context.Context, courseID int) ([]domain.SectionWithDetails, error) {
-	const query = `
+// sectionDetailsQuery selects the SectionWithDetails projection; callers
+// append a WHERE clause
+const sectionDetailsQuery = `
 		SELECT s.id, s.course_id, s.section_number, s.section_type,
             s.professor_id, s.total_seats, s.available_seats, s.parent_section_id,
             c.id, c.course_code, c.course_name, c.credits,
-            p.id, p.first_name, p.last_name, p.email, p.rating
+            p.first_name, p.last_name, p.email, p.rating
         FROM sections s
         JOIN courses c ON s.course_id = c.id
-        LEFT JOIN professors p ON s.professor_id = p.id
+        LEFT JOIN professors p ON s.professor_id = p.id`
+
+func (s *Storage) GetSectionsForCourse(ctx context.Context, courseID int) ([]domain.SectionWithDetails, error) {
+	const query = sectionDetailsQuery + `
         WHERE s.course_id = $1
         ORDER BY s.section_type, s.section_number
 	`
 
-	rows, err := s.pool.Query(ctx, query, courseID)
+	return s.querySectionDetails(ctx, query, courseID)
+}
+
+// GetSectionDetails returns one section with its course, professor and
+// meetings, or pgx.ErrNoRows if it does not exist
+func (s *Storage) GetSectionDetails(ctx context.Context, sectionID int) (*domain.SectionWithDetails, error) {
+	const query = sectionDetailsQuery + `
+        WHERE s.id = $1
+	`
+
+	sections, err := s.querySectionDetails(ctx, query, sectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sections) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	return &sections[0], nil
+}
+
+func (s *Storage) querySectionDetails(ctx context.Context, query string, args ...any) ([]domain.SectionWithDetails, error) {
+	rows, err := s.pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -100,16 +127,18 @@ func (s *Storage) GetSectionsForCourse(ctx context.Context, courseID int) ([]dom
 	defer rows.Close()
 
 	var sections []domain.SectionWithDetails
+	var sectionIDs []int
 	for rows.Next() {
 		var sd domain.SectionWithDetails
 		var course domain.Course
 		var prof domain.Professor
+		var profFirstName, profLastName *string
 
 		err := rows.Scan(
 			&sd.ID, &sd.CourseID, &sd.SectionNumber, &sd.SectionType,
 			&sd.ProfessorID, &sd.TotalSeats, &sd.AvailableSeats, &sd.ParentSectionID,
 			&course.ID, &course.CourseCode, &course.CourseName, &course.Credits,
-			&prof.ID, &prof.FirstName, &prof.LastName, &prof.Email, &prof.Rating,
+			&profFirstName, &profLastName, &prof.Email, &prof.Rating,
 		)
 		if err != nil {
 			return nil, err
@@ -117,19 +146,27 @@ func (s *Storage) GetSectionsForCourse(ctx context.Context, courseID int) ([]dom
 
 		sd.Course = course
 
-		if sd.ProfessorID != nil {
+		if sd.ProfessorID != nil && profFirstName != nil && profLastName != nil {
+			prof.ID = *sd.ProfessorID
+			prof.FirstName = *profFirstName
+			prof.LastName = *profLastName
 			sd.Professor = &prof
 		}
 
-		// Get meetings for this section
-		meetings, err := s.GetSectionMeetings(ctx, sd.ID)
-		if err != nil {
-			return nil, err
-		}
-
-		sd.Meetings = meetings
-
 		sections = append(sections, sd)
+		sectionIDs = append(sectionIDs, sd.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Meetings for all the sections in one query
+	meetings, err := s.getMeetingsForSections(ctx, sectionIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sections {
+		sections[i].Meetings = meetings[sections[i].ID]
 	}
 
 	return sections, nil
@@ -137,7 +174,7 @@ func (s *Storage) GetSectionsForCourse(ctx context.Context, courseID int) ([]dom
 
 func (s *Storage) GetSectionMeetings(ctx context.Context, sectionID int) ([]domain.SectionMeeting, error) {
 	const query = `
-		SELECT id, section_id, day_of_week, start_time::text, end_time::text, room, building
+		SELECT id, section_id, day_of_week, start_time::text, end_time::text, room, building, room_id
 		FROM section_meetings
 		WHERE section_id = $1
 		        ORDER BY 
@@ -169,6 +206,7 @@ func (s *Storage) GetSectionMeetings(ctx context.Context, sectionID int) ([]doma
 			&m.EndTime,
 			&m.Room,
 			&m.Building,
+			&m.RoomID,
 		)
 
 		if err != nil {
@@ -181,9 +219,47 @@ func (s *Storage) GetSectionMeetings(ctx context.Context, sectionID int) ([]doma
 	return meetings, nil
 }
 
+// getMeetingsForSections returns the meetings of every listed section, keyed
+// by section id, in one query
+func (s *Storage) getMeetingsForSections(ctx context.Context, sectionIDs []int) (map[int][]domain.SectionMeeting, error) {
+	const query = `
+		SELECT id, section_id, day_of_week, start_time::text, end_time::text, room, building, room_id
+		FROM section_meetings
+		WHERE section_id = ANY($1)
+		ORDER BY section_id,
+            CASE day_of_week
+                WHEN 'Monday' THEN 1
+                WHEN 'Tuesday' THEN 2
+                WHEN 'Wednesday' THEN 3
+                WHEN 'Thursday' THEN 4
+                WHEN 'Friday' THEN 5
+                WHEN 'Saturday' THEN 6
+                WHEN 'Sunday' THEN 7
+            END;
+	`
+
+	rows, err := s.pool.Query(ctx, query, sectionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	meetings := make(map[int][]domain.SectionMeeting)
+	for rows.Next() {
+		var m domain.SectionMeeting
+		err := rows.Scan(&m.ID, &m.SectionID, &m.DayOfWeek, &m.StartTime, &m.EndTime, &m.Room, &m.Building, &m.RoomID)
+		if err != nil {
+			return nil, err
+		}
+		meetings[m.SectionID] = append(meetings[m.SectionID], m)
+	}
+
+	return meetings, rows.Err()
+}
+
 func (s *Storage) GetMeetingByID(ctx context.Context, meetingID int) (domain.SectionMeeting, error) {
 	const query = `
-		SELECT id, section_id, day_of_week, start_time::text, end_time::text, room, building
+		SELECT id, section_id, day_of_week, start_time::text, end_time::text, room, building, room_id
 		FROM section_meetings
 		WHERE id = $1;
 	`
@@ -197,7 +273,31 @@ func (s *Storage) GetMeetingByID(ctx context.Context, meetingID int) (domain.Sec
 		&m.EndTime,
 		&m.Room,
 		&m.Building,
+		&m.RoomID,
 	)
 
 	return m, err
 }
+
+func (s *Storage) GetCourseForSection(ctx context.Context, sectionID int) (*domain.Course, error) {
+	const query = `
+		SELECT c.id, c.course_code, c.course_name, c.credits, c.is_internship, c.description, c.semester, c.created_at
+		FROM sections s
+		JOIN courses c ON s.course_id = c.id
+		WHERE s.id = $1;
+	`
+
+	var c domain.Course
+	err := s.pool.QueryRow(ctx, query, sectionID).Scan(
+		&c.ID,
+		&c.CourseCode,
+		&c.CourseName,
+		&c.Credits,
+		&c.IsInternship,
+		&c.Description,
+		&c.Semester,
+		&c.CreatedAt,
+	)
+
+	return &c, err
+}
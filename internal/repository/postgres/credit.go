@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"scheduler/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetCreditLimit returns the per-term limit for a year/standing pair,
+// falling back to domain.DefaultCreditLimit when none is configured
+func (s *Storage) GetCreditLimit(ctx context.Context, yearOfStudy int, standing string) (*domain.CreditLimit, error) {
+	const query = `
+		SELECT id, year_of_study, standing, min_credits, max_credits
+		FROM credit_limits
+		WHERE year_of_study = $1 AND standing = $2;
+	`
+
+	var l domain.CreditLimit
+	err := s.pool.QueryRow(ctx, query, yearOfStudy, standing).Scan(
+		&l.ID,
+		&l.YearOfStudy,
+		&l.Standing,
+		&l.MinCredits,
+		&l.MaxCredits,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		l = domain.DefaultCreditLimit
+		l.YearOfStudy = yearOfStudy
+		l.Standing = standing
+		return &l, nil
+	}
+
+	return &l, err
+}
@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+	"scheduler/internal/domain"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// collect runs query in tx and scans every row with scan
+func collect[T any](ctx context.Context, tx pgx.Tx, scan func(pgx.Row) (*T, error), query string, args ...any) ([]T, error) {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	items := []T{}
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+
+	return items, rows.Err()
+}
+
+func scanExportedSchedule(row pgx.Row) (*domain.Schedule, error) {
+	var sch domain.Schedule
+	err := row.Scan(&sch.ID, &sch.StudentID, &sch.ScheduleName, &sch.Description, &sch.IsSubmitted, &sch.Status, &sch.CreatedAt)
+	return &sch, err
+}
+
+func scanExportedScheduleSection(row pgx.Row) (*domain.ExportedScheduleSection, error) {
+	var ss domain.ExportedScheduleSection
+	err := row.Scan(&ss.ID, &ss.ScheduleID, &ss.SectionID, &ss.MeetingID, &ss.AddedAt, &ss.CourseCode, &ss.SectionNumber)
+	return &ss, err
+}
+
+func scanExportedReview(row pgx.Row) (*domain.ScheduleReview, error) {
+	var r domain.ScheduleReview
+	err := row.Scan(&r.ID, &r.ScheduleID, &r.AdvisorID, &r.Decision, &r.Comment, &r.CreatedAt)
+	return &r, err
+}
+
+func scanExportedGroup(row pgx.Row) (*domain.ExportedGroupMembership, error) {
+	var g domain.ExportedGroupMembership
+	err := row.Scan(&g.GroupID, &g.GroupName, &g.OwnerID, &g.ScheduleID, &g.Status, &g.InvitedAt, &g.JoinedAt)
+	return &g, err
+}
+
+func scanExportedIdentity(row pgx.Row) (*domain.LinkedIdentity, error) {
+	var i domain.LinkedIdentity
+	err := row.Scan(&i.Issuer, &i.Subject, &i.Email, &i.CreatedAt, &i.LastLoginAt)
+	return &i, err
+}
+
+func scanExportedAuditEntry(row pgx.Row) (*domain.AuditEntry, error) {
+	var a domain.AuditEntry
+	err := row.Scan(&a.ID, &a.Event, &a.StudentID, &a.Email, &a.IPAddress, &a.UserAgent, &a.Detail, &a.CreatedAt)
+	return &a, err
+}
+
+// ExportStudentData gathers everything stored about a student for the data
+// export. It reads in one repeatable-read transaction so the parts agree
+// with each other, and queries each table once for the whole account.
+func (s *Storage) ExportStudentData(ctx context.Context, studentID int) (*domain.AccountExport, error) {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	student, err := scanStudent(tx.QueryRow(ctx, `SELECT `+studentColumns+` FROM students WHERE id = $1;`, studentID))
+	if err != nil {
+		return nil, err
+	}
+
+	export := &domain.AccountExport{ExportedAt: time.Now(), Student: *student}
+
+	const schedules = `
+		SELECT id, student_id, schedule_name, description, is_submitted, status, created_at
+		FROM schedules
+		WHERE student_id = $1
+		ORDER BY created_at;`
+	if export.Schedules, err = collect(ctx, tx, scanExportedSchedule, schedules, studentID); err != nil {
+		return nil, err
+	}
+
+	const scheduleSections = `
+		SELECT ss.id, ss.schedule_id, ss.section_id, ss.meeting_id, ss.added_at, c.course_code, s.section_number
+		FROM schedule_sections ss
+		JOIN schedules sch ON sch.id = ss.schedule_id
+		JOIN sections s ON s.id = ss.section_id
+		JOIN courses c ON c.id = s.course_id
+		WHERE sch.student_id = $1
+		ORDER BY ss.schedule_id, ss.added_at;`
+	if export.ScheduleSections, err = collect(ctx, tx, scanExportedScheduleSection, scheduleSections, studentID); err != nil {
+		return nil, err
+	}
+
+	const blocks = `
+		SELECT ` + blockColumns + `
+		FROM schedule_blocks
+		WHERE schedule_id IN (SELECT id FROM schedules WHERE student_id = $1)
+		ORDER BY schedule_id, start_time;`
+	if export.BusyBlocks, err = collect(ctx, tx, scanBlock, blocks, studentID); err != nil {
+		return nil, err
+	}
+
+	// Reviews of the student's schedules, and for advisors the ones they wrote
+	const reviews = `
+		SELECT id, schedule_id, advisor_id, decision, comment, created_at
+		FROM schedule_reviews
+		WHERE advisor_id = $1 OR schedule_id IN (SELECT id FROM schedules WHERE student_id = $1)
+		ORDER BY created_at;`
+	if export.Reviews, err = collect(ctx, tx, scanExportedReview, reviews, studentID); err != nil {
+		return nil, err
+	}
+
+	const shares = `
+		SELECT ` + shareColumns + `
+		FROM schedule_shares
+		WHERE schedule_id IN (SELECT id FROM schedules WHERE student_id = $1)
+		ORDER BY created_at;`
+	if export.Shares, err = collect(ctx, tx, scanShare, shares, studentID); err != nil {
+		return nil, err
+	}
+
+	const watchlist = `SELECT ` + watchColumns + ` FROM watchlist_items WHERE student_id = $1 ORDER BY created_at;`
+	if export.Watchlist, err = collect(ctx, tx, scanWatchlistItem, watchlist, studentID); err != nil {
+		return nil, err
+	}
+
+	const notifications = `SELECT ` + notificationColumns + ` FROM notifications WHERE student_id = $1 ORDER BY created_at;`
+	if export.Notifications, err = collect(ctx, tx, scanNotification, notifications, studentID); err != nil {
+		return nil, err
+	}
+
+	const petitions = `SELECT ` + petitionColumns + ` FROM overload_petitions WHERE student_id = $1 ORDER BY created_at;`
+	if export.Petitions, err = collect(ctx, tx, scanPetition, petitions, studentID); err != nil {
+		return nil, err
+	}
+
+	// Invitations are included alongside joined groups, told apart by status
+	const groups = `
+		SELECT g.id, g.name, g.owner_id, m.schedule_id, m.status, m.invited_at, m.joined_at
+		FROM group_members m
+		JOIN study_groups g ON g.id = m.group_id
+		WHERE m.student_id = $1
+		ORDER BY m.invited_at;`
+	if export.Groups, err = collect(ctx, tx, scanExportedGroup, groups, studentID); err != nil {
+		return nil, err
+	}
+
+	// Every session, including revoked and expired ones
+	const sessions = `SELECT ` + sessionColumns + ` FROM sessions WHERE student_id = $1 ORDER BY created_at;`
+	if export.Sessions, err = collect(ctx, tx, scanSession, sessions, studentID); err != nil {
+		return nil, err
+	}
+
+	const identities = `
+		SELECT issuer, subject, email, created_at, last_login_at
+		FROM oidc_identities
+		WHERE student_id = $1
+		ORDER BY created_at;`
+	if export.Identities, err = collect(ctx, tx, scanExportedIdentity, identities, studentID); err != nil {
+		return nil, err
+	}
+
+	const auditLog = `SELECT ` + auditColumns + ` FROM audit_log WHERE student_id = $1 ORDER BY created_at, id;`
+	if export.AuditLog, err = collect(ctx, tx, scanExportedAuditEntry, auditLog, studentID); err != nil {
+		return nil, err
+	}
+
+	return export, tx.Commit(ctx)
+}
@@ -0,0 +1,188 @@
+package postgres
+
+import (
+	"context"
+	"scheduler/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateGroup creates a study group with the owner as its first joined member
+func (s *Storage) CreateGroup(ctx context.Context, ownerID int, name string) (*domain.StudyGroup, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	const query = `
+		INSERT INTO study_groups (name, owner_id)
+		VALUES ($1, $2)
+		RETURNING id, name, owner_id, created_at;`
+
+	var g domain.StudyGroup
+	err = tx.QueryRow(ctx, query, name, ownerID).Scan(&g.ID, &g.Name, &g.OwnerID, &g.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	const memberQuery = `
+		INSERT INTO group_members (group_id, student_id, status, joined_at)
+		VALUES ($1, $2, 'joined', NOW());`
+
+	if _, err := tx.Exec(ctx, memberQuery, g.ID, ownerID); err != nil {
+		return nil, err
+	}
+
+	return &g, tx.Commit(ctx)
+}
+
+func (s *Storage) GetGroupByID(ctx context.Context, groupID int) (*domain.StudyGroup, error) {
+	const query = `SELECT id, name, owner_id, created_at FROM study_groups WHERE id = $1;`
+
+	var g domain.StudyGroup
+	err := s.pool.QueryRow(ctx, query, groupID).Scan(&g.ID, &g.Name, &g.OwnerID, &g.CreatedAt)
+	return &g, err
+}
+
+// GetStudentGroups lists groups the student has joined or been invited to
+func (s *Storage) GetStudentGroups(ctx context.Context, studentID int) ([]domain.StudyGroup, error) {
+	const query = `
+		SELECT g.id, g.name, g.owner_id, g.created_at
+		FROM study_groups g
+		JOIN group_members m ON m.group_id = g.id
+		WHERE m.student_id = $1
+		ORDER BY g.created_at DESC;`
+
+	rows, err := s.pool.Query(ctx, query, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	var groups []domain.StudyGroup
+	for rows.Next() {
+		var g domain.StudyGroup
+		if err := rows.Scan(&g.ID, &g.Name, &g.OwnerID, &g.CreatedAt); err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+
+	return groups, rows.Err()
+}
+
+const groupMemberColumns = `m.group_id, m.student_id, st.first_name || ' ' || st.last_name,
+		m.schedule_id, m.status, m.invited_at, m.joined_at`
+
+func scanGroupMember(row pgx.Row) (*domain.GroupMember, error) {
+	var m domain.GroupMember
+	err := row.Scan(
+		&m.GroupID,
+		&m.StudentID,
+		&m.StudentName,
+		&m.ScheduleID,
+		&m.Status,
+		&m.InvitedAt,
+		&m.JoinedAt,
+	)
+	return &m, err
+}
+
+func (s *Storage) GetGroupMembers(ctx context.Context, groupID int) ([]domain.GroupMember, error) {
+	const query = `
+		SELECT ` + groupMemberColumns + `
+		FROM group_members m
+		JOIN students st ON m.student_id = st.id
+		WHERE m.group_id = $1
+		ORDER BY m.invited_at;`
+
+	rows, err := s.pool.Query(ctx, query, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	var members []domain.GroupMember
+	for rows.Next() {
+		m, err := scanGroupMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, *m)
+	}
+
+	return members, rows.Err()
+}
+
+func (s *Storage) GetGroupMember(ctx context.Context, groupID, studentID int) (*domain.GroupMember, error) {
+	const query = `
+		SELECT ` + groupMemberColumns + `
+		FROM group_members m
+		JOIN students st ON m.student_id = st.id
+		WHERE m.group_id = $1 AND m.student_id = $2;`
+
+	return scanGroupMember(s.pool.QueryRow(ctx, query, groupID, studentID))
+}
+
+// InviteToGroup invites a student; inviting an existing member is a no-op
+func (s *Storage) InviteToGroup(ctx context.Context, groupID, studentID int) error {
+	const query = `
+		INSERT INTO group_members (group_id, student_id)
+		VALUES ($1, $2)
+		ON CONFLICT (group_id, student_id) DO NOTHING;`
+
+	_, err := s.pool.Exec(ctx, query, groupID, studentID)
+	return err
+}
+
+// JoinGroup accepts a pending invitation; it returns pgx.ErrNoRows if there is none
+func (s *Storage) JoinGroup(ctx context.Context, groupID, studentID int) error {
+	const query = `
+		UPDATE group_members
+		SET status = 'joined', joined_at = NOW()
+		WHERE group_id = $1 AND student_id = $2 AND status = 'invited';`
+
+	tag, err := s.pool.Exec(ctx, query, groupID, studentID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+// LeaveGroup removes a member or declines an invitation
+func (s *Storage) LeaveGroup(ctx context.Context, groupID, studentID int) error {
+	const query = `DELETE FROM group_members WHERE group_id = $1 AND student_id = $2;`
+	_, err := s.pool.Exec(ctx, query, groupID, studentID)
+	return err
+}
+
+func (s *Storage) SetGroupSchedule(ctx context.Context, groupID, studentID, scheduleID int) error {
+	const query = `UPDATE group_members SET schedule_id = $3 WHERE group_id = $1 AND student_id = $2;`
+	_, err := s.pool.Exec(ctx, query, groupID, studentID, scheduleID)
+	return err
+}
+
+// handOverGroups passes every group the student owns to the other joined
+// member who joined first. Groups without one are left to be deleted with
+// the student.
+func handOverGroups(ctx context.Context, db execer, studentID int) error {
+	const query = `
+		UPDATE study_groups g
+		SET owner_id = heir.student_id
+		FROM (
+			SELECT DISTINCT ON (m.group_id) m.group_id, m.student_id
+			FROM group_members m
+			WHERE m.student_id <> $1 AND m.status = 'joined'
+			ORDER BY m.group_id, m.joined_at, m.student_id
+		) heir
+		WHERE heir.group_id = g.id AND g.owner_id = $1;`
+
+	_, err := db.Exec(ctx, query, studentID)
+	return err
+}
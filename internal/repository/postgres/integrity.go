@@ -0,0 +1,213 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"scheduler/internal/domain"
+)
+
+// overlapColumns selects two overlapping meetings a and b of different
+// sections, plus the shared resource named by resource
+const overlapColumns = `
+		a.id, b.id, sa.id, sb.id,
+		ca.course_code || '-' || sa.section_number,
+		cb.course_code || '-' || sb.section_number,
+		a.day_of_week,
+		GREATEST(a.start_time, b.start_time)::text,
+		LEAST(a.end_time, b.end_time)::text`
+
+const overlapJoins = `
+		JOIN sections sa ON a.section_id = sa.id
+		JOIN courses ca ON sa.course_id = ca.id
+		JOIN sections sb ON b.section_id = sb.id
+		JOIN courses cb ON sb.course_id = cb.id`
+
+// overlapCondition pairs each overlapping meeting once
+const overlapCondition = `
+		a.id < b.id
+		AND a.section_id <> b.section_id
+		AND a.day_of_week = b.day_of_week
+		AND a.start_time < b.end_time
+		AND b.start_time < a.end_time`
+
+// GetCatalogIntegrityReport scans the imported catalog for double-booked
+// rooms and professors, sections larger than their rooms and meetings
+// without a room. Meetings with impossible times never get this far: the
+// schema rejects them and SeedDatabase logs the ones it skipped.
+func (s *Storage) GetCatalogIntegrityReport(ctx context.Context) (*domain.IntegrityReport, error) {
+	var issues []domain.IntegrityIssue
+
+	checks := []func(context.Context) ([]domain.IntegrityIssue, error){
+		s.findRoomDoubleBookings,
+		s.findProfessorDoubleBookings,
+		s.findOverCapacitySections,
+		s.findMeetingsWithoutRoom,
+	}
+	for _, check := range checks {
+		found, err := check(ctx)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, found...)
+	}
+
+	return domain.NewIntegrityReport(issues), nil
+}
+
+// queryOverlaps runs a query selecting a resource name followed by
+// overlapColumns and turns each row into an issue
+func (s *Storage) queryOverlaps(ctx context.Context, kind, query string) ([]domain.IntegrityIssue, error) {
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	var issues []domain.IntegrityIssue
+	for rows.Next() {
+		var resource, first, second, day, start, end string
+		var meetingA, meetingB, sectionA, sectionB int
+		err := rows.Scan(
+			&resource,
+			&meetingA,
+			&meetingB,
+			&sectionA,
+			&sectionB,
+			&first,
+			&second,
+			&day,
+			&start,
+			&end,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		issues = append(issues, domain.IntegrityIssue{
+			Kind:       kind,
+			Severity:   domain.SeverityError,
+			Message:    fmt.Sprintf("%s is booked by %s and %s on %s %s-%s", resource, first, second, day, start, end),
+			SectionIDs: []int{sectionA, sectionB},
+			MeetingIDs: []int{meetingA, meetingB},
+		})
+	}
+
+	return issues, rows.Err()
+}
+
+func (s *Storage) findRoomDoubleBookings(ctx context.Context) ([]domain.IntegrityIssue, error) {
+	const query = `
+		SELECT TRIM(r.building_code || ' ' || r.room_number),` + overlapColumns + `
+		FROM section_meetings a
+		JOIN section_meetings b ON a.room_id = b.room_id
+		JOIN rooms r ON a.room_id = r.id` + overlapJoins + `
+		WHERE` + overlapCondition + `
+		ORDER BY r.building_code, r.room_number, a.day_of_week, a.start_time;`
+
+	return s.queryOverlaps(ctx, domain.IssueRoomDoubleBooked, query)
+}
+
+func (s *Storage) findProfessorDoubleBookings(ctx context.Context) ([]domain.IntegrityIssue, error) {
+	const query = `
+		SELECT p.first_name || ' ' || p.last_name,` + overlapColumns + `
+		FROM section_meetings a
+		JOIN section_meetings b ON a.day_of_week = b.day_of_week` + overlapJoins + `
+		JOIN professors p ON sa.professor_id = p.id
+		WHERE sa.professor_id = sb.professor_id
+		  AND` + overlapCondition + `
+		ORDER BY p.last_name, p.first_name, a.day_of_week, a.start_time;`
+
+	return s.queryOverlaps(ctx, domain.IssueProfessorDoubleBooked, query)
+}
+
+// findOverCapacitySections reports sections with more seats than a room they
+// meet in holds: an error once enrollment exceeds the room, a warning before
+func (s *Storage) findOverCapacitySections(ctx context.Context) ([]domain.IntegrityIssue, error) {
+	const query = `
+		SELECT s.id, array_agg(m.id ORDER BY m.id), c.course_code || '-' || s.section_number,
+		       s.total_seats, s.total_seats - s.available_seats,
+		       TRIM(r.building_code || ' ' || r.room_number), r.capacity
+		FROM sections s
+		JOIN courses c ON s.course_id = c.id
+		JOIN section_meetings m ON m.section_id = s.id
+		JOIN rooms r ON m.room_id = r.id
+		WHERE r.capacity IS NOT NULL AND s.total_seats > r.capacity
+		GROUP BY s.id, c.course_code, r.id
+		ORDER BY c.course_code, s.section_number;`
+
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	var issues []domain.IntegrityIssue
+	for rows.Next() {
+		var sectionID, seats, enrolled, capacity int
+		var meetingIDs []int
+		var section, room string
+		if err := rows.Scan(&sectionID, &meetingIDs, &section, &seats, &enrolled, &room, &capacity); err != nil {
+			return nil, err
+		}
+
+		issue := domain.IntegrityIssue{
+			Kind:       domain.IssueOverCapacity,
+			Severity:   domain.SeverityWarning,
+			Message:    fmt.Sprintf("%s has %d seats but %s holds %d", section, seats, room, capacity),
+			SectionIDs: []int{sectionID},
+			MeetingIDs: meetingIDs,
+		}
+		if enrolled > capacity {
+			issue.Severity = domain.SeverityError
+			issue.Message = fmt.Sprintf("%s has %d enrolled but %s holds %d", section, enrolled, room, capacity)
+		}
+		issues = append(issues, issue)
+	}
+
+	return issues, rows.Err()
+}
+
+// queryMeetingIssues turns rows of (meeting id, section id, message) into issues
+func (s *Storage) queryMeetingIssues(ctx context.Context, kind, severity, query string) ([]domain.IntegrityIssue, error) {
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	var issues []domain.IntegrityIssue
+	for rows.Next() {
+		var meetingID, sectionID int
+		var message string
+		if err := rows.Scan(&meetingID, &sectionID, &message); err != nil {
+			return nil, err
+		}
+
+		issues = append(issues, domain.IntegrityIssue{
+			Kind:       kind,
+			Severity:   severity,
+			Message:    message,
+			SectionIDs: []int{sectionID},
+			MeetingIDs: []int{meetingID},
+		})
+	}
+
+	return issues, rows.Err()
+}
+
+// findMeetingsWithoutRoom flags meetings with no catalog room, except those
+// imported as held online or with the room still TBA
+func (s *Storage) findMeetingsWithoutRoom(ctx context.Context) ([]domain.IntegrityIssue, error) {
+	const query = `
+		SELECT m.id, s.id,
+		       c.course_code || '-' || s.section_number || ' has no room on ' || m.day_of_week || ' '
+		           || m.start_time::text || '-' || m.end_time::text
+		FROM section_meetings m
+		JOIN sections s ON m.section_id = s.id
+		JOIN courses c ON s.course_id = c.id
+		WHERE m.room_id IS NULL
+		  AND LOWER(COALESCE(m.location, '')) NOT IN ('online', 'tba')
+		ORDER BY c.course_code, s.section_number;`
+
+	return s.queryMeetingIssues(ctx, domain.IssueMissingRoom, domain.SeverityWarning, query)
+}
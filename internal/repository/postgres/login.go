@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"scheduler/internal/domain"
+	"scheduler/internal/utils"
+	"strings"
+	"time"
+)
+
+// LoginThrottleKeys returns the counter keys for a login attempt: one for the
+// account email and one for the client IP
+func LoginThrottleKeys(email, ip string) (accountKey, ipKey string) {
+	return "email:" + strings.ToLower(strings.TrimSpace(email)), "ip:" + ip
+}
+
+// GetLoginLockout returns how long the longest active lock on any of the
+// keys still lasts, or 0 if none is locked
+func (s *Storage) GetLoginLockout(ctx context.Context, keys ...string) (time.Duration, error) {
+	const query = `
+		SELECT COALESCE(MAX(EXTRACT(EPOCH FROM locked_until - NOW())), 0)::float8
+		FROM login_throttles
+		WHERE key = ANY($1) AND locked_until > NOW();`
+
+	var seconds float64
+	err := s.pool.QueryRow(ctx, query, keys).Scan(&seconds)
+	return time.Duration(seconds * float64(time.Second)), err
+}
+
+// RecordLoginFailure counts a failed login against the account and IP
+// counters, locks whichever reached its limit and writes the audit entry, all
+// in one transaction. It returns the length of the lock this attempt
+// triggered, or 0 if it did not trigger one.
+func (s *Storage) RecordLoginFailure(ctx context.Context, email, ip string, policy utils.LoginPolicy, entry domain.AuditEntry) (time.Duration, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	// A counter whose last failure is older than the window starts over
+	const countQuery = `
+		INSERT INTO login_throttles (key, failures, last_failure_at)
+		VALUES ($1, 1, NOW())
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE
+				WHEN login_throttles.last_failure_at < NOW() - make_interval(secs => $2) THEN 1
+				ELSE login_throttles.failures + 1
+			END,
+			last_failure_at = NOW()
+		RETURNING failures;`
+
+	const lockQuery = `
+		UPDATE login_throttles
+		SET locked_until = NOW() + make_interval(secs => $2)
+		WHERE key = $1;`
+
+	accountKey, ipKey := LoginThrottleKeys(email, ip)
+	limits := map[string]int{accountKey: policy.MaxAccountFailures, ipKey: policy.MaxIPFailures}
+
+	var longest time.Duration
+	for _, key := range []string{accountKey, ipKey} {
+		var failures int
+		if err := tx.QueryRow(ctx, countQuery, key, policy.Window.Seconds()).Scan(&failures); err != nil {
+			return 0, err
+		}
+
+		lockout := policy.Lockout(failures, limits[key])
+		if lockout == 0 {
+			continue
+		}
+
+		if _, err := tx.Exec(ctx, lockQuery, key, lockout.Seconds()); err != nil {
+			return 0, err
+		}
+		longest = max(longest, lockout)
+	}
+
+	if err := insertAuditEntry(ctx, tx, entry); err != nil {
+		return 0, err
+	}
+
+	return longest, tx.Commit(ctx)
+}
+
+// ClearLoginFailures resets the account counter after a successful login.
+// The IP counter is left alone so one good password does not unlock guessing
+// at other accounts from the same address.
+func (s *Storage) ClearLoginFailures(ctx context.Context, email string) error {
+	accountKey, _ := LoginThrottleKeys(email, "")
+	_, err := s.pool.Exec(ctx, `DELETE FROM login_throttles WHERE key = $1;`, accountKey)
+	return err
+}
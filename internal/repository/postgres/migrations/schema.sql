@@ -124,4 +124,518 @@ END $$;
 UPDATE courses
 SET is_internship = TRUE
 WHERE LOWER(course_name) LIKE '%internship%'
-  AND is_internship = FALSE;
\ No newline at end of file
+  AND is_internship = FALSE;
+
+ALTER TABLE students ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'student';
+ALTER TABLE students ADD COLUMN IF NOT EXISTS standing VARCHAR(20) NOT NULL DEFAULT 'good';
+
+CREATE TABLE IF NOT EXISTS credit_limits (
+                               id SERIAL PRIMARY KEY,
+                               year_of_study INTEGER NOT NULL CHECK (year_of_study >= 1 AND year_of_study <= 5),
+                               standing VARCHAR(20) NOT NULL,
+                               min_credits INTEGER NOT NULL CHECK (min_credits >= 0),
+                               max_credits INTEGER NOT NULL,
+
+                               CHECK (max_credits >= min_credits),
+                               UNIQUE(year_of_study, standing)
+);
+
+-- Default per-term limits; edit rows in credit_limits to change them
+INSERT INTO credit_limits (year_of_study, standing, min_credits, max_credits)
+SELECT y, st, 12,
+       CASE
+           WHEN st = 'probation' THEN 24
+           WHEN y = 1 THEN 30
+           ELSE 36
+       END
+FROM generate_series(1, 5) AS y, (VALUES ('good'), ('probation')) AS standings(st)
+ON CONFLICT (year_of_study, standing) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS overload_petitions (
+                                    id SERIAL PRIMARY KEY,
+                                    student_id INTEGER NOT NULL,
+                                    schedule_id INTEGER NOT NULL,
+                                    requested_credits INTEGER NOT NULL CHECK (requested_credits > 0),
+                                    reason TEXT NOT NULL,
+                                    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+                                        CHECK (status IN ('pending', 'approved', 'denied')),
+                                    reviewer_id INTEGER,
+                                    reviewer_comment TEXT,
+                                    created_at TIMESTAMP DEFAULT NOW(),
+                                    reviewed_at TIMESTAMP,
+
+                                    FOREIGN KEY (student_id) REFERENCES students(id) ON DELETE CASCADE,
+                                    FOREIGN KEY (schedule_id) REFERENCES schedules(id) ON DELETE CASCADE,
+                                    FOREIGN KEY (reviewer_id) REFERENCES students(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_overload_petitions_schedule ON overload_petitions(schedule_id);
+CREATE INDEX IF NOT EXISTS idx_overload_petitions_status ON overload_petitions(status);
+
+-- One pending petition per schedule. Older duplicates filed before the
+-- index existed are closed first so it can be created.
+UPDATE overload_petitions p
+SET status = 'denied', reviewer_comment = 'superseded by a newer petition', reviewed_at = NOW()
+WHERE p.status = 'pending'
+  AND EXISTS (
+    SELECT 1 FROM overload_petitions q
+    WHERE q.schedule_id = p.schedule_id AND q.status = 'pending' AND q.id > p.id
+  );
+CREATE UNIQUE INDEX IF NOT EXISTS idx_overload_petitions_one_pending
+    ON overload_petitions(schedule_id) WHERE status = 'pending';
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.table_constraints
+        WHERE table_name = 'students' AND constraint_name = 'students_role_check'
+    ) THEN
+        ALTER TABLE students ADD CONSTRAINT students_role_check
+            CHECK (role IN ('student', 'advisor', 'professor', 'admin'));
+    END IF;
+END $$;
+
+ALTER TABLE schedules ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'draft'
+    CHECK (status IN ('draft', 'submitted', 'approved', 'changes_requested', 'enrolled'));
+UPDATE schedules SET status = 'submitted' WHERE is_submitted = TRUE AND status = 'draft';
+
+ALTER TABLE students ADD COLUMN IF NOT EXISTS advisor_id INTEGER REFERENCES students(id) ON DELETE SET NULL;
+
+CREATE TABLE IF NOT EXISTS schedule_reviews (
+                                  id SERIAL PRIMARY KEY,
+                                  schedule_id INTEGER NOT NULL,
+                                  advisor_id INTEGER,
+                                  decision VARCHAR(20) NOT NULL
+                                      CHECK (decision IN ('approved', 'changes_requested')),
+                                  comment TEXT,
+                                  created_at TIMESTAMP DEFAULT NOW(),
+
+                                  FOREIGN KEY (schedule_id) REFERENCES schedules(id) ON DELETE CASCADE,
+                                  FOREIGN KEY (advisor_id) REFERENCES students(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_students_advisor ON students(advisor_id);
+CREATE INDEX IF NOT EXISTS idx_schedules_status ON schedules(status);
+CREATE INDEX IF NOT EXISTS idx_schedule_reviews_schedule ON schedule_reviews(schedule_id);
+
+CREATE TABLE IF NOT EXISTS schedule_shares (
+                                 id SERIAL PRIMARY KEY,
+                                 schedule_id INTEGER NOT NULL,
+                                 token VARCHAR(64) UNIQUE NOT NULL,
+                                 display_name VARCHAR(100) NOT NULL,
+                                 expires_at TIMESTAMP,
+                                 revoked_at TIMESTAMP,
+                                 created_at TIMESTAMP DEFAULT NOW(),
+
+                                 FOREIGN KEY (schedule_id) REFERENCES schedules(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedule_shares_schedule ON schedule_shares(schedule_id);
+
+CREATE TABLE IF NOT EXISTS study_groups (
+                              id SERIAL PRIMARY KEY,
+                              name VARCHAR(100) NOT NULL,
+                              owner_id INTEGER NOT NULL,
+                              created_at TIMESTAMP DEFAULT NOW(),
+
+                              FOREIGN KEY (owner_id) REFERENCES students(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS group_members (
+                               group_id INTEGER NOT NULL,
+                               student_id INTEGER NOT NULL,
+                               schedule_id INTEGER,
+                               status VARCHAR(20) NOT NULL DEFAULT 'invited'
+                                   CHECK (status IN ('invited', 'joined')),
+                               invited_at TIMESTAMP DEFAULT NOW(),
+                               joined_at TIMESTAMP,
+
+                               PRIMARY KEY (group_id, student_id),
+                               FOREIGN KEY (group_id) REFERENCES study_groups(id) ON DELETE CASCADE,
+                               FOREIGN KEY (student_id) REFERENCES students(id) ON DELETE CASCADE,
+                               FOREIGN KEY (schedule_id) REFERENCES schedules(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_members_student ON group_members(student_id);
+
+CREATE TABLE IF NOT EXISTS schedule_blocks (
+                                 id SERIAL PRIMARY KEY,
+                                 schedule_id INTEGER NOT NULL,
+                                 label VARCHAR(100) NOT NULL,
+                                 days TEXT[] NOT NULL,
+                                 start_time TIME NOT NULL,
+                                 end_time TIME NOT NULL CHECK (end_time > start_time),
+                                 location VARCHAR(100),
+                                 created_at TIMESTAMP DEFAULT NOW(),
+
+                                 FOREIGN KEY (schedule_id) REFERENCES schedules(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedule_blocks_schedule ON schedule_blocks(schedule_id);
+
+CREATE TABLE IF NOT EXISTS buildings (
+                           code VARCHAR(50) PRIMARY KEY,
+                           name VARCHAR(200),
+                           latitude DOUBLE PRECISION CHECK (latitude BETWEEN -90 AND 90),
+                           longitude DOUBLE PRECISION CHECK (longitude BETWEEN -180 AND 180),
+                           wheelchair_access BOOLEAN NOT NULL DEFAULT FALSE,
+                           accessibility_notes TEXT,
+                           created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Walking times are symmetric and stored once per pair with from_building < to_building
+CREATE TABLE IF NOT EXISTS walking_times (
+                               from_building VARCHAR(50) NOT NULL,
+                               to_building VARCHAR(50) NOT NULL,
+                               minutes INTEGER NOT NULL CHECK (minutes >= 0),
+
+                               PRIMARY KEY (from_building, to_building),
+                               CHECK (from_building < to_building),
+                               FOREIGN KEY (from_building) REFERENCES buildings(code) ON DELETE CASCADE ON UPDATE CASCADE,
+                               FOREIGN KEY (to_building) REFERENCES buildings(code) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- Migration: register every building already referenced by a meeting
+INSERT INTO buildings (code)
+SELECT DISTINCT building FROM section_meetings WHERE building IS NOT NULL
+ON CONFLICT (code) DO NOTHING;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.table_constraints
+        WHERE table_name = 'section_meetings' AND constraint_name = 'fk_section_meetings_building'
+    ) THEN
+        ALTER TABLE section_meetings ADD CONSTRAINT fk_section_meetings_building
+            FOREIGN KEY (building) REFERENCES buildings(code) ON UPDATE CASCADE;
+    END IF;
+END $$;
+
+CREATE TABLE IF NOT EXISTS rooms (
+                       id SERIAL PRIMARY KEY,
+                       building_code VARCHAR(50) NOT NULL,
+                       room_number VARCHAR(50) NOT NULL DEFAULT '',
+                       capacity INTEGER CHECK (capacity > 0),
+                       features TEXT[] NOT NULL DEFAULT '{}',
+                       created_at TIMESTAMP DEFAULT NOW(),
+
+                       FOREIGN KEY (building_code) REFERENCES buildings(code) ON DELETE CASCADE ON UPDATE CASCADE,
+                       UNIQUE(building_code, room_number)
+);
+
+ALTER TABLE section_meetings ADD COLUMN IF NOT EXISTS room_id INTEGER REFERENCES rooms(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_section_meetings_room ON section_meetings(room_id);
+
+-- Migration: register rooms already referenced by meetings (capacity is filled on the next import)
+INSERT INTO rooms (building_code, room_number)
+SELECT DISTINCT building, COALESCE(room, '') FROM section_meetings WHERE building IS NOT NULL
+ON CONFLICT (building_code, room_number) DO NOTHING;
+
+UPDATE section_meetings m
+SET room_id = r.id
+FROM rooms r
+WHERE m.room_id IS NULL
+  AND m.building = r.building_code
+  AND COALESCE(m.room, '') = r.room_number;
+
+-- Migration: rooms written "<building>.<room>" (e.g. "8.302") used to be
+-- seeded as a building named after the whole code. Split them the way the
+-- seeder now does, keeping the largest capacity and any coordinates set on
+-- the old code, then drop the old buildings. Walking times set for the old
+-- codes are deleted with them, as they were per room, not per building.
+INSERT INTO buildings (code, latitude, longitude)
+SELECT DISTINCT ON (substring(code FROM '^(\d+[A-Za-z]?)\.'))
+       substring(code FROM '^(\d+[A-Za-z]?)\.'), latitude, longitude
+FROM buildings
+WHERE code ~ '^\d+[A-Za-z]?\.\S+$'
+ORDER BY substring(code FROM '^(\d+[A-Za-z]?)\.'), latitude IS NULL, code
+ON CONFLICT (code) DO UPDATE
+SET latitude = COALESCE(buildings.latitude, EXCLUDED.latitude),
+    longitude = COALESCE(buildings.longitude, EXCLUDED.longitude);
+
+INSERT INTO rooms (building_code, room_number, capacity)
+SELECT substring(building_code FROM '^(\d+[A-Za-z]?)\.'),
+       substring(building_code FROM '^\d+[A-Za-z]?\.(\S+)$'),
+       MAX(capacity)
+FROM rooms
+WHERE building_code ~ '^\d+[A-Za-z]?\.\S+$' AND room_number = ''
+GROUP BY 1, 2
+ON CONFLICT (building_code, room_number) DO UPDATE
+SET capacity = GREATEST(rooms.capacity, EXCLUDED.capacity);
+
+UPDATE section_meetings m
+SET building = r.building_code,
+    room = r.room_number,
+    room_id = r.id
+FROM rooms r
+WHERE m.building ~ '^\d+[A-Za-z]?\.\S+$'
+  AND r.building_code = substring(m.building FROM '^(\d+[A-Za-z]?)\.')
+  AND r.room_number = substring(m.building FROM '^\d+[A-Za-z]?\.(\S+)$');
+
+DELETE FROM buildings b
+WHERE b.code ~ '^\d+[A-Za-z]?\.\S+$'
+  AND NOT EXISTS (SELECT 1 FROM section_meetings m WHERE m.building = b.code);
+
+-- The room column as imported, so meetings held online or not yet given a
+-- room can be told apart from rooms missing from the catalog
+ALTER TABLE section_meetings ADD COLUMN IF NOT EXISTS location VARCHAR(100);
+
+-- Migration: "online" and "TBA" used to be seeded as buildings
+UPDATE section_meetings
+SET location = building, building = NULL, room = NULL, room_id = NULL
+WHERE LOWER(building) IN ('online', 'tba');
+
+DELETE FROM buildings b
+WHERE LOWER(b.code) IN ('online', 'tba')
+  AND NOT EXISTS (SELECT 1 FROM section_meetings m WHERE m.building = b.code);
+
+CREATE TABLE IF NOT EXISTS watchlist_items (
+                       id SERIAL PRIMARY KEY,
+                       student_id INTEGER NOT NULL,
+                       section_id INTEGER,
+                       course_id INTEGER,
+                       note TEXT,
+                       created_at TIMESTAMP DEFAULT NOW(),
+
+                       FOREIGN KEY (student_id) REFERENCES students(id) ON DELETE CASCADE,
+                       FOREIGN KEY (section_id) REFERENCES sections(id) ON DELETE CASCADE,
+                       FOREIGN KEY (course_id) REFERENCES courses(id) ON DELETE CASCADE,
+                       CHECK ((section_id IS NULL) <> (course_id IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_watchlist_section ON watchlist_items(student_id, section_id) WHERE section_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_watchlist_course ON watchlist_items(student_id, course_id) WHERE course_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS notifications (
+                       id SERIAL PRIMARY KEY,
+                       student_id INTEGER NOT NULL,
+                       kind VARCHAR(50) NOT NULL,
+                       section_id INTEGER,
+                       title VARCHAR(200) NOT NULL,
+                       body TEXT NOT NULL,
+                       read_at TIMESTAMP,
+                       created_at TIMESTAMP DEFAULT NOW(),
+
+                       FOREIGN KEY (student_id) REFERENCES students(id) ON DELETE CASCADE,
+                       FOREIGN KEY (section_id) REFERENCES sections(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_student ON notifications(student_id, created_at DESC);
+
+-- Sections going from full to having a free seat, queued for the seat alert worker
+CREATE TABLE IF NOT EXISTS seat_openings (
+                       id BIGSERIAL PRIMARY KEY,
+                       section_id INTEGER NOT NULL,
+                       available_seats INTEGER NOT NULL,
+                       created_at TIMESTAMP DEFAULT NOW(),
+
+                       FOREIGN KEY (section_id) REFERENCES sections(id) ON DELETE CASCADE
+);
+
+CREATE OR REPLACE FUNCTION record_seat_opening() RETURNS trigger AS $$
+BEGIN
+    IF OLD.available_seats <= 0 AND NEW.available_seats > 0 THEN
+        INSERT INTO seat_openings (section_id, available_seats) VALUES (NEW.id, NEW.available_seats);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_sections_seat_opening ON sections;
+CREATE TRIGGER trg_sections_seat_opening
+    AFTER UPDATE OF available_seats ON sections
+    FOR EACH ROW EXECUTE FUNCTION record_seat_opening();
+
+-- Publish seat count changes so every API instance can stream them to clients
+CREATE OR REPLACE FUNCTION notify_seat_change() RETURNS trigger AS $$
+BEGIN
+    IF NEW.available_seats IS DISTINCT FROM OLD.available_seats
+       OR NEW.total_seats IS DISTINCT FROM OLD.total_seats THEN
+        PERFORM pg_notify('seat_changes', json_build_object(
+            'section_id', NEW.id,
+            'available_seats', NEW.available_seats,
+            'total_seats', NEW.total_seats
+        )::text);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_sections_seat_change ON sections;
+CREATE TRIGGER trg_sections_seat_change
+    AFTER UPDATE OF available_seats, total_seats ON sections
+    FOR EACH ROW EXECUTE FUNCTION notify_seat_change();
+
+-- A session is one login; its refresh tokens form a single rotation family
+CREATE TABLE IF NOT EXISTS sessions (
+                       id SERIAL PRIMARY KEY,
+                       student_id INTEGER NOT NULL,
+                       user_agent TEXT,
+                       ip_address VARCHAR(64),
+                       created_at TIMESTAMP DEFAULT NOW(),
+                       last_used_at TIMESTAMP DEFAULT NOW(),
+                       expires_at TIMESTAMP NOT NULL,
+                       revoked_at TIMESTAMP,
+
+                       FOREIGN KEY (student_id) REFERENCES students(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_student ON sessions(student_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_expires ON sessions(expires_at);
+CREATE INDEX IF NOT EXISTS idx_sessions_revoked ON sessions(revoked_at);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+                       id SERIAL PRIMARY KEY,
+                       session_id INTEGER NOT NULL,
+                       token_hash CHAR(64) UNIQUE NOT NULL,
+                       created_at TIMESTAMP DEFAULT NOW(),
+                       expires_at TIMESTAMP NOT NULL,
+                       used_at TIMESTAMP,
+
+                       FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+-- Access tokens revoked before they expire, by jti
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+                       jti VARCHAR(64) PRIMARY KEY,
+                       expires_at TIMESTAMP NOT NULL
+);
+
+-- Accounts created before email verification existed count as verified
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'students' AND column_name = 'email_verified_at'
+    ) THEN
+        ALTER TABLE students ADD COLUMN email_verified_at TIMESTAMP;
+        UPDATE students SET email_verified_at = COALESCE(created_at, NOW());
+    END IF;
+END $$;
+
+-- Single-use email verification and password reset tokens, stored hashed
+CREATE TABLE IF NOT EXISTS account_tokens (
+                       id SERIAL PRIMARY KEY,
+                       student_id INTEGER NOT NULL,
+                       purpose VARCHAR(20) NOT NULL CHECK (purpose IN ('verify_email', 'reset_password')),
+                       token_hash CHAR(64) UNIQUE NOT NULL,
+                       created_at TIMESTAMP DEFAULT NOW(),
+                       expires_at TIMESTAMP NOT NULL,
+                       used_at TIMESTAMP,
+
+                       FOREIGN KEY (student_id) REFERENCES students(id) ON DELETE CASCADE
+);
+
+-- Failed login counters, keyed 'email:<address>' or 'ip:<address>'
+CREATE TABLE IF NOT EXISTS login_throttles (
+                       key VARCHAR(320) PRIMARY KEY,
+                       failures INTEGER NOT NULL DEFAULT 0,
+                       last_failure_at TIMESTAMP NOT NULL DEFAULT NOW(),
+                       locked_until TIMESTAMP
+);
+
+-- Security-relevant events such as failed logins
+CREATE TABLE IF NOT EXISTS audit_log (
+                       id SERIAL PRIMARY KEY,
+                       event VARCHAR(50) NOT NULL,
+                       student_id INTEGER,
+                       email VARCHAR(255),
+                       ip_address VARCHAR(64),
+                       user_agent TEXT,
+                       detail TEXT,
+                       created_at TIMESTAMP DEFAULT NOW(),
+
+                       FOREIGN KEY (student_id) REFERENCES students(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_created ON audit_log(created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_student ON audit_log(student_id);
+
+-- TOTP second factor. The secret is stored at enrollment, encrypted under
+-- JWT_SECRET like the signing keys, but only takes effect once a first code
+-- sets totp_enabled_at; totp_last_step stops a code from being used twice.
+ALTER TABLE students ADD COLUMN IF NOT EXISTS totp_secret_sealed BYTEA;
+ALTER TABLE students ADD COLUMN IF NOT EXISTS totp_enabled_at TIMESTAMP;
+ALTER TABLE students ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE students ADD COLUMN IF NOT EXISTS two_factor_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+                       id SERIAL PRIMARY KEY,
+                       student_id INTEGER NOT NULL,
+                       code_hash CHAR(64) NOT NULL,
+                       created_at TIMESTAMP DEFAULT NOW(),
+                       used_at TIMESTAMP,
+
+                       FOREIGN KEY (student_id) REFERENCES students(id) ON DELETE CASCADE,
+                       UNIQUE (student_id, code_hash)
+);
+
+-- Issued by a password login that still needs the second factor
+CREATE TABLE IF NOT EXISTS login_challenges (
+                       id SERIAL PRIMARY KEY,
+                       student_id INTEGER NOT NULL,
+                       token_hash CHAR(64) UNIQUE NOT NULL,
+                       created_at TIMESTAMP DEFAULT NOW(),
+                       expires_at TIMESTAMP NOT NULL,
+                       attempts INTEGER NOT NULL DEFAULT 0,
+                       used_at TIMESTAMP,
+
+                       FOREIGN KEY (student_id) REFERENCES students(id) ON DELETE CASCADE
+);
+
+-- Single sign-on logins between the redirect to the provider and the callback
+CREATE TABLE IF NOT EXISTS oidc_logins (
+                       state_hash CHAR(64) PRIMARY KEY,
+                       nonce VARCHAR(64) NOT NULL,
+                       code_verifier VARCHAR(128) NOT NULL,
+                       created_at TIMESTAMP DEFAULT NOW(),
+                       expires_at TIMESTAMP NOT NULL
+);
+
+-- Identity provider accounts linked to students
+CREATE TABLE IF NOT EXISTS oidc_identities (
+                       id SERIAL PRIMARY KEY,
+                       student_id INTEGER NOT NULL,
+                       issuer VARCHAR(255) NOT NULL,
+                       subject VARCHAR(255) NOT NULL,
+                       email VARCHAR(255),
+                       created_at TIMESTAMP DEFAULT NOW(),
+                       last_login_at TIMESTAMP DEFAULT NOW(),
+
+                       FOREIGN KEY (student_id) REFERENCES students(id) ON DELETE CASCADE,
+                       UNIQUE (issuer, subject)
+);
+
+-- Access token signing keys. private_key is PKCS #8 sealed with a key
+-- derived from JWT_SECRET.
+CREATE TABLE IF NOT EXISTS signing_keys (
+                       kid VARCHAR(32) PRIMARY KEY,
+                       algorithm VARCHAR(10) NOT NULL CHECK (algorithm IN ('EdDSA', 'RS256')),
+                       private_key BYTEA NOT NULL,
+                       created_at TIMESTAMPTZ DEFAULT NOW(),
+                       activates_at TIMESTAMPTZ NOT NULL,
+                       retires_at TIMESTAMPTZ NOT NULL,
+                       expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- Keys for internal services calling the API. Only the SHA-256 of a key is
+-- kept; prefix is its first characters, for telling keys apart.
+CREATE TABLE IF NOT EXISTS api_keys (
+                       id SERIAL PRIMARY KEY,
+                       name VARCHAR(100) NOT NULL,
+                       prefix VARCHAR(16) NOT NULL,
+                       key_hash CHAR(64) NOT NULL UNIQUE,
+                       scopes TEXT[] NOT NULL,
+                       created_by INTEGER,
+                       created_at TIMESTAMP DEFAULT NOW(),
+                       expires_at TIMESTAMP,
+                       last_used_at TIMESTAMP,
+                       last_used_ip VARCHAR(64),
+                       revoked_at TIMESTAMP,
+
+                       FOREIGN KEY (created_by) REFERENCES students(id) ON DELETE SET NULL
+);
+
+-- Sessions started by an admin acting as the student
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS impersonator_id INTEGER REFERENCES students(id) ON DELETE CASCADE;
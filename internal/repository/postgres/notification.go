@@ -0,0 +1,153 @@
+package postgres
+
+import (
+	"context"
+	"scheduler/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const notificationColumns = `id, student_id, kind, section_id, title, body, read_at, created_at`
+
+func scanNotification(row pgx.Row) (*domain.Notification, error) {
+	var n domain.Notification
+	err := row.Scan(
+		&n.ID,
+		&n.StudentID,
+		&n.Kind,
+		&n.SectionID,
+		&n.Title,
+		&n.Body,
+		&n.ReadAt,
+		&n.CreatedAt,
+	)
+	return &n, err
+}
+
+// GetNotifications returns a student's feed, newest first
+func (s *Storage) GetNotifications(ctx context.Context, studentID int, unreadOnly bool, limit int) ([]domain.Notification, error) {
+	const query = `
+		SELECT ` + notificationColumns + `
+		FROM notifications
+		WHERE student_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC
+		LIMIT $3;`
+
+	rows, err := s.pool.Query(ctx, query, studentID, unreadOnly, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	notifications := []domain.Notification{}
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, *n)
+	}
+
+	return notifications, rows.Err()
+}
+
+// MarkNotificationRead returns pgx.ErrNoRows if the notification does not
+// belong to the student
+func (s *Storage) MarkNotificationRead(ctx context.Context, studentID, notificationID int) error {
+	const query = `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, NOW())
+		WHERE id = $1 AND student_id = $2;`
+
+	tag, err := s.pool.Exec(ctx, query, notificationID, studentID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+func (s *Storage) MarkAllNotificationsRead(ctx context.Context, studentID int) error {
+	const query = `UPDATE notifications SET read_at = NOW() WHERE student_id = $1 AND read_at IS NULL;`
+	_, err := s.pool.Exec(ctx, query, studentID)
+	return err
+}
+
+// ProcessSeatOpenings consumes queued seat openings and creates a
+// seat_available notification for every student watching the section or its
+// course. A student is not alerted about the same section again within
+// cooldownMinutes, so a section flapping between 0 and 1 seats alerts once.
+// Openings are claimed with SKIP LOCKED, so several API instances can run the
+// worker at once.
+func (s *Storage) ProcessSeatOpenings(ctx context.Context, cooldownMinutes, batchSize int) ([]domain.NotificationDelivery, error) {
+	const query = `
+		WITH claimed AS (
+		    DELETE FROM seat_openings
+		    WHERE id IN (
+		        SELECT id FROM seat_openings ORDER BY id LIMIT $2 FOR UPDATE SKIP LOCKED
+		    )
+		    RETURNING section_id
+		),
+		watchers AS (
+		    SELECT DISTINCT w.student_id, s.id AS section_id
+		    FROM (SELECT DISTINCT section_id FROM claimed) o
+		    JOIN sections s ON s.id = o.section_id
+		    JOIN watchlist_items w ON w.section_id = s.id OR w.course_id = s.course_id
+		    WHERE s.available_seats > 0
+		      AND NOT EXISTS (
+		          SELECT 1 FROM notifications n
+		          WHERE n.student_id = w.student_id
+		            AND n.section_id = s.id
+		            AND n.kind = 'seat_available'
+		            AND n.created_at > NOW() - make_interval(mins => $1)
+		      )
+		),
+		inserted AS (
+		    INSERT INTO notifications (student_id, kind, section_id, title, body)
+		    SELECT wt.student_id, 'seat_available', s.id,
+		           c.course_code || ' ' || s.section_number || ' has an open seat',
+		           c.course_code || ' ' || s.section_number || ' (' || c.course_name || ') now has '
+		               || s.available_seats || ' of ' || s.total_seats || ' seats available.'
+		    FROM watchers wt
+		    JOIN sections s ON s.id = wt.section_id
+		    JOIN courses c ON s.course_id = c.id
+		    RETURNING ` + notificationColumns + `
+		)
+		SELECT i.id, i.student_id, i.kind, i.section_id, i.title, i.body, i.read_at, i.created_at,
+		       st.email, st.first_name
+		FROM inserted i
+		JOIN students st ON st.id = i.student_id;`
+
+	rows, err := s.pool.Query(ctx, query, cooldownMinutes, batchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	var deliveries []domain.NotificationDelivery
+	for rows.Next() {
+		var d domain.NotificationDelivery
+		err := rows.Scan(
+			&d.ID,
+			&d.StudentID,
+			&d.Kind,
+			&d.SectionID,
+			&d.Title,
+			&d.Body,
+			&d.ReadAt,
+			&d.CreatedAt,
+			&d.Email,
+			&d.FirstName,
+		)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
@@ -0,0 +1,203 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"scheduler/internal/domain"
+	"scheduler/internal/utils"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateOIDCLogin remembers the nonce and PKCE verifier of a login that was
+// sent to the identity provider, and clears out abandoned ones
+func (s *Storage) CreateOIDCLogin(ctx context.Context, stateHash, nonce, verifier string, expiresAt time.Time) error {
+	if _, err := s.pool.Exec(ctx, `DELETE FROM oidc_logins WHERE expires_at < NOW();`); err != nil {
+		return err
+	}
+
+	const query = `
+		INSERT INTO oidc_logins (state_hash, nonce, code_verifier, expires_at)
+		VALUES ($1, $2, $3, $4);`
+
+	_, err := s.pool.Exec(ctx, query, stateHash, nonce, verifier, expiresAt)
+	return err
+}
+
+// ConsumeOIDCLogin returns and deletes the login started with state, or
+// pgx.ErrNoRows if there is none or it expired
+func (s *Storage) ConsumeOIDCLogin(ctx context.Context, stateHash string) (nonce, verifier string, err error) {
+	const query = `
+		DELETE FROM oidc_logins
+		WHERE state_hash = $1 AND expires_at > NOW()
+		RETURNING nonce, code_verifier;`
+
+	err = s.pool.QueryRow(ctx, query, stateHash).Scan(&nonce, &verifier)
+	return nonce, verifier, err
+}
+
+// LoginWithOIDC finds the student for an external identity. An identity seen
+// before maps to its student; a new one is linked to the student with the
+// same email if the provider verified that email, and otherwise a student is
+// created from the identity's claims using provisionStudentID when the
+// provider has no student ID. linked reports whether an existing account was
+// linked and created whether a new one was made. It returns
+// utils.ErrUnverifiedEmail when the email belongs to an account but the
+// provider did not verify it, and the errors of provisionStudent.
+//
+// An account whose email was never verified locally may have been
+// registered by someone else before its owner signed in, so linking it
+// also takes it over: see claimUnverifiedAccount.
+func (s *Storage) LoginWithOIDC(ctx context.Context, identity domain.ExternalIdentity, provisionStudentID string) (student *domain.Student, linked, created bool, err error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, false, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	const findQuery = `
+		UPDATE oidc_identities
+		SET last_login_at = NOW(), email = $3
+		WHERE issuer = $1 AND subject = $2
+		RETURNING student_id;`
+
+	var studentID int
+	err = tx.QueryRow(ctx, findQuery, identity.Issuer, identity.Subject, identity.Email).Scan(&studentID)
+	switch {
+	case err == nil:
+	case !errors.Is(err, pgx.ErrNoRows):
+		return nil, false, false, err
+	default:
+		var verified bool
+		const emailQuery = `SELECT id, email_verified_at IS NOT NULL FROM students WHERE LOWER(email) = LOWER($1) FOR UPDATE;`
+		err = tx.QueryRow(ctx, emailQuery, identity.Email).Scan(&studentID, &verified)
+		switch {
+		case err == nil:
+			if !identity.EmailVerified {
+				return nil, false, false, utils.ErrUnverifiedEmail
+			}
+			if !verified {
+				if err := claimUnverifiedAccount(ctx, tx, studentID); err != nil {
+					return nil, false, false, err
+				}
+			}
+			linked = true
+		case !errors.Is(err, pgx.ErrNoRows):
+			return nil, false, false, err
+		default:
+			if studentID, err = provisionStudent(ctx, tx, identity, provisionStudentID); err != nil {
+				return nil, false, false, err
+			}
+			created = true
+		}
+
+		const linkQuery = `
+			INSERT INTO oidc_identities (student_id, issuer, subject, email)
+			VALUES ($1, $2, $3, $4);`
+
+		if _, err := tx.Exec(ctx, linkQuery, studentID, identity.Issuer, identity.Subject, identity.Email); err != nil {
+			return nil, false, false, err
+		}
+	}
+
+	// The provider vouching for the email counts as verifying it here too
+	query := `SELECT ` + studentColumns + ` FROM students WHERE id = $1;`
+	if identity.EmailVerified {
+		query = `
+			UPDATE students
+			SET email_verified_at = COALESCE(email_verified_at, NOW())
+			WHERE id = $1
+			RETURNING ` + studentColumns + `;`
+	}
+
+	student, err = scanStudent(tx.QueryRow(ctx, query, studentID))
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	return student, linked, created, tx.Commit(ctx)
+}
+
+// claimUnverifiedAccount removes every way into an account other than the
+// identity being linked: the password, the second factor and all sessions.
+// Until its email was verified, the account may belong to whoever registered
+// it rather than to the owner of the address.
+func claimUnverifiedAccount(ctx context.Context, tx pgx.Tx, studentID int) error {
+	const query = `
+		UPDATE students
+		SET password_hash = '', totp_secret_sealed = NULL, totp_enabled_at = NULL, totp_last_step = 0
+		WHERE id = $1;`
+
+	if _, err := tx.Exec(ctx, query, studentID); err != nil {
+		return err
+	}
+
+	for _, query := range []string{
+		`DELETE FROM recovery_codes WHERE student_id = $1;`,
+		`DELETE FROM login_challenges WHERE student_id = $1;`,
+		`DELETE FROM account_tokens WHERE student_id = $1;`,
+	} {
+		if _, err := tx.Exec(ctx, query, studentID); err != nil {
+			return err
+		}
+	}
+
+	_, err := revokeOtherSessions(ctx, tx, studentID, 0)
+	return err
+}
+
+// maxStudentIDLength is the size of students.student_id
+const maxStudentIDLength = 20
+
+// provisionStudent creates an account without a password; its owner can set
+// one later through password reset. It returns utils.ErrInvalidStudentID if
+// the provider's student ID does not fit and utils.ErrStudentIDTaken if
+// another account already has it.
+func provisionStudent(ctx context.Context, tx pgx.Tx, identity domain.ExternalIdentity, provisionStudentID string) (int, error) {
+	studentID := identity.StudentID
+	if studentID == "" {
+		studentID = provisionStudentID
+	}
+	if utf8.RuneCountInString(studentID) > maxStudentIDLength {
+		return 0, utils.ErrInvalidStudentID
+	}
+
+	const query = `
+		INSERT INTO students (email, password_hash, first_name, last_name, student_id, year_of_study)
+		VALUES ($1, '', $2, $3, $4, 1)
+		RETURNING id;`
+
+	var id int
+	err := tx.QueryRow(ctx, query, identity.Email, identity.GivenName, identity.FamilyName, studentID).Scan(&id)
+	if isConstraintViolation(err, uniqueViolation, "students_student_id_key") {
+		return 0, utils.ErrStudentIDTaken
+	}
+	return id, err
+}
+
+func (s *Storage) GetLinkedIdentities(ctx context.Context, studentID int) ([]domain.LinkedIdentity, error) {
+	const query = `
+		SELECT issuer, subject, email, created_at, last_login_at
+		FROM oidc_identities
+		WHERE student_id = $1
+		ORDER BY created_at;`
+
+	rows, err := s.pool.Query(ctx, query, studentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []domain.LinkedIdentity{}
+	for rows.Next() {
+		var i domain.LinkedIdentity
+		if err := rows.Scan(&i.Issuer, &i.Subject, &i.Email, &i.CreatedAt, &i.LastLoginAt); err != nil {
+			return nil, err
+		}
+		identities = append(identities, i)
+	}
+
+	return identities, rows.Err()
+}
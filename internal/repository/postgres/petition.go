@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"scheduler/internal/domain"
+	"scheduler/internal/utils"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const petitionColumns = `id, student_id, schedule_id, requested_credits, reason, status,
+		reviewer_id, reviewer_comment, created_at, reviewed_at`
+
+func scanPetition(row pgx.Row) (*domain.OverloadPetition, error) {
+	var p domain.OverloadPetition
+	err := row.Scan(
+		&p.ID,
+		&p.StudentID,
+		&p.ScheduleID,
+		&p.RequestedCredits,
+		&p.Reason,
+		&p.Status,
+		&p.ReviewerID,
+		&p.ReviewerComment,
+		&p.CreatedAt,
+		&p.ReviewedAt,
+	)
+	return &p, err
+}
+
+func (s *Storage) queryPetitions(ctx context.Context, query string, args ...any) ([]domain.OverloadPetition, error) {
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	var petitions []domain.OverloadPetition
+	for rows.Next() {
+		p, err := scanPetition(rows)
+		if err != nil {
+			return nil, err
+		}
+		petitions = append(petitions, *p)
+	}
+
+	return petitions, rows.Err()
+}
+
+// CreateOverloadPetition files a petition for a schedule. A schedule can have
+// only one pending petition at a time; a second returns utils.ErrPetitionPending.
+func (s *Storage) CreateOverloadPetition(ctx context.Context, studentID, scheduleID int, req *domain.CreatePetitionRequest) (*domain.OverloadPetition, error) {
+	const query = `
+		INSERT INTO overload_petitions (student_id, schedule_id, requested_credits, reason)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + petitionColumns + `;`
+
+	petition, err := scanPetition(s.pool.QueryRow(ctx, query, studentID, scheduleID, req.RequestedCredits, req.Reason))
+	if isConstraintViolation(err, uniqueViolation, "idx_overload_petitions_one_pending") {
+		return nil, utils.ErrPetitionPending
+	}
+	return petition, err
+}
+
+func (s *Storage) GetPetitionByID(ctx context.Context, petitionID int) (*domain.OverloadPetition, error) {
+	const query = `SELECT ` + petitionColumns + ` FROM overload_petitions WHERE id = $1;`
+	return scanPetition(s.pool.QueryRow(ctx, query, petitionID))
+}
+
+func (s *Storage) GetStudentPetitions(ctx context.Context, studentID int) ([]domain.OverloadPetition, error) {
+	const query = `
+		SELECT ` + petitionColumns + `
+		FROM overload_petitions
+		WHERE student_id = $1
+		ORDER BY created_at DESC;`
+
+	return s.queryPetitions(ctx, query, studentID)
+}
+
+// GetPendingPetitions lists petitions awaiting a decision from the students
+// advised by advisorID, or from every student when advisorID is nil
+func (s *Storage) GetPendingPetitions(ctx context.Context, advisorID *int) ([]domain.OverloadPetition, error) {
+	const query = `
+		SELECT ` + petitionColumns + `
+		FROM overload_petitions
+		WHERE status = 'pending'
+		  AND ($1::int IS NULL OR student_id IN (SELECT id FROM students WHERE advisor_id = $1))
+		ORDER BY created_at;`
+
+	return s.queryPetitions(ctx, query, advisorID)
+}
+
+// ReviewPetition records an advisor decision; only pending petitions can be reviewed
+func (s *Storage) ReviewPetition(ctx context.Context, petitionID, reviewerID int, req *domain.ReviewPetitionRequest) (*domain.OverloadPetition, error) {
+	const query = `
+		UPDATE overload_petitions
+		SET status = $3, reviewer_id = $2, reviewer_comment = $4, reviewed_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+		RETURNING ` + petitionColumns + `;`
+
+	return scanPetition(s.pool.QueryRow(ctx, query, petitionID, reviewerID, req.Status, req.Comment))
+}
+
+// GetApprovedOverloadCredits returns the highest approved credit count for a
+// schedule, or 0 if no petition has been approved
+func (s *Storage) GetApprovedOverloadCredits(ctx context.Context, scheduleID int) (int, error) {
+	const query = `
+		SELECT COALESCE(MAX(requested_credits), 0)
+		FROM overload_petitions
+		WHERE schedule_id = $1 AND status = 'approved';`
+
+	var credits int
+	err := s.pool.QueryRow(ctx, query, scheduleID).Scan(&credits)
+	return credits, err
+}
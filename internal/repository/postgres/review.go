@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"context"
+	"scheduler/internal/domain"
+	"scheduler/internal/utils"
+)
+
+// ReviewSchedule moves a submitted schedule to the advisor's decision
+// (approved or changes_requested) and records the decision and comment
+func (s *Storage) ReviewSchedule(ctx context.Context, scheduleID, advisorID int, decision string, comment *string) (*domain.ScheduleReview, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := updateScheduleStatus(ctx, tx, scheduleID, decision); err != nil {
+		return nil, err
+	}
+
+	const query = `
+		INSERT INTO schedule_reviews (schedule_id, advisor_id, decision, comment)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, schedule_id, advisor_id, decision, comment, created_at;`
+
+	var r domain.ScheduleReview
+	err = tx.QueryRow(ctx, query, scheduleID, advisorID, decision, comment).Scan(
+		&r.ID,
+		&r.ScheduleID,
+		&r.AdvisorID,
+		&r.Decision,
+		&r.Comment,
+		&r.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, tx.Commit(ctx)
+}
+
+func (s *Storage) GetScheduleReviews(ctx context.Context, scheduleID int) ([]domain.ScheduleReview, error) {
+	const query = `
+		SELECT id, schedule_id, advisor_id, decision, comment, created_at
+		FROM schedule_reviews
+		WHERE schedule_id = $1
+		ORDER BY created_at DESC;`
+
+	rows, err := s.pool.Query(ctx, query, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	var reviews []domain.ScheduleReview
+	for rows.Next() {
+		var r domain.ScheduleReview
+		err := rows.Scan(
+			&r.ID,
+			&r.ScheduleID,
+			&r.AdvisorID,
+			&r.Decision,
+			&r.Comment,
+			&r.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		reviews = append(reviews, r)
+	}
+
+	return reviews, rows.Err()
+}
+
+// GetAdviseeSchedules lists schedules in the given status belonging to
+// students assigned to the advisor
+func (s *Storage) GetAdviseeSchedules(ctx context.Context, advisorID int, status string) ([]domain.AdviseeSchedule, error) {
+	const query = `
+		SELECT sc.id, sc.student_id, sc.schedule_name, sc.description, sc.is_submitted, sc.status, sc.created_at,
+		       st.first_name || ' ' || st.last_name, st.student_id
+		FROM schedules sc
+		JOIN students st ON sc.student_id = st.id
+		WHERE st.advisor_id = $1 AND sc.status = $2
+		ORDER BY sc.created_at;`
+
+	rows, err := s.pool.Query(ctx, query, advisorID, status)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	var schedules []domain.AdviseeSchedule
+	for rows.Next() {
+		var sch domain.AdviseeSchedule
+		err := rows.Scan(
+			&sch.ID,
+			&sch.StudentID,
+			&sch.ScheduleName,
+			&sch.Description,
+			&sch.IsSubmitted,
+			&sch.Status,
+			&sch.CreatedAt,
+			&sch.StudentName,
+			&sch.StudentNumber,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		schedules = append(schedules, sch)
+	}
+
+	return schedules, rows.Err()
+}
+
+// EnrollSchedule moves an approved schedule to enrolled and takes one seat
+// in every section on it. Nothing changes if any section is full.
+func (s *Storage) EnrollSchedule(ctx context.Context, scheduleID int) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := updateScheduleStatus(ctx, tx, scheduleID, domain.ScheduleEnrolled); err != nil {
+		return err
+	}
+
+	const countQuery = `SELECT COUNT(DISTINCT section_id) FROM schedule_sections WHERE schedule_id = $1;`
+
+	var sectionCount int64
+	if err := tx.QueryRow(ctx, countQuery, scheduleID).Scan(&sectionCount); err != nil {
+		return err
+	}
+
+	const seatQuery = `
+		UPDATE sections
+		SET available_seats = available_seats - 1
+		WHERE id IN (SELECT section_id FROM schedule_sections WHERE schedule_id = $1)
+		  AND available_seats > 0;`
+
+	tag, err := tx.Exec(ctx, seatQuery, scheduleID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() != sectionCount {
+		return utils.ErrSectionFull
+	}
+
+	return tx.Commit(ctx)
+}
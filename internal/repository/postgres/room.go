@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"scheduler/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const roomColumns = `r.id, r.building_code, r.room_number, r.capacity, r.features, r.created_at`
+
+func scanRoom(row pgx.Row) (*domain.Room, error) {
+	var r domain.Room
+	err := row.Scan(
+		&r.ID,
+		&r.BuildingCode,
+		&r.RoomNumber,
+		&r.Capacity,
+		&r.Features,
+		&r.CreatedAt,
+	)
+	return &r, err
+}
+
+func (s *Storage) queryRooms(ctx context.Context, query string, args ...any) ([]domain.Room, error) {
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	rooms := []domain.Room{}
+	for rows.Next() {
+		r, err := scanRoom(rows)
+		if err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, *r)
+	}
+
+	return rooms, rows.Err()
+}
+
+// GetRooms lists rooms, optionally restricted to one building
+func (s *Storage) GetRooms(ctx context.Context, building string) ([]domain.Room, error) {
+	const query = `
+		SELECT ` + roomColumns + `
+		FROM rooms r
+		WHERE $1 = '' OR r.building_code = $1
+		ORDER BY r.building_code, r.room_number;`
+
+	return s.queryRooms(ctx, query, building)
+}
+
+func (s *Storage) GetRoomByID(ctx context.Context, roomID int) (*domain.Room, error) {
+	const query = `SELECT ` + roomColumns + ` FROM rooms r WHERE r.id = $1;`
+	return scanRoom(s.pool.QueryRow(ctx, query, roomID))
+}
+
+func (s *Storage) GetRoomBookings(ctx context.Context, roomID int) ([]domain.RoomBooking, error) {
+	const query = `
+		SELECT m.id, s.id, c.course_code, s.section_number, m.day_of_week, m.start_time::text, m.end_time::text
+		FROM section_meetings m
+		JOIN sections s ON m.section_id = s.id
+		JOIN courses c ON s.course_id = c.id
+		WHERE m.room_id = $1
+		ORDER BY
+            CASE m.day_of_week
+                WHEN 'Monday' THEN 1
+                WHEN 'Tuesday' THEN 2
+                WHEN 'Wednesday' THEN 3
+                WHEN 'Thursday' THEN 4
+                WHEN 'Friday' THEN 5
+                WHEN 'Saturday' THEN 6
+                WHEN 'Sunday' THEN 7
+            END,
+            m.start_time;`
+
+	rows, err := s.pool.Query(ctx, query, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	bookings := []domain.RoomBooking{}
+	for rows.Next() {
+		var b domain.RoomBooking
+		err := rows.Scan(
+			&b.MeetingID,
+			&b.SectionID,
+			&b.CourseCode,
+			&b.SectionNumber,
+			&b.DayOfWeek,
+			&b.StartTime,
+			&b.EndTime,
+		)
+		if err != nil {
+			return nil, err
+		}
+		bookings = append(bookings, b)
+	}
+
+	return bookings, rows.Err()
+}
+
+// GetFreeRooms lists rooms with no meeting overlapping [start, end) on the
+// given day. Rooms of unknown capacity are only included when minCapacity is 0.
+func (s *Storage) GetFreeRooms(ctx context.Context, day, start, end string, minCapacity int) ([]domain.Room, error) {
+	const query = `
+		SELECT ` + roomColumns + `
+		FROM rooms r
+		WHERE ($4 = 0 OR r.capacity >= $4)
+		  AND NOT EXISTS (
+		      SELECT 1 FROM section_meetings m
+		      WHERE m.room_id = r.id
+		        AND m.day_of_week = $1
+		        AND m.start_time < $3::time
+		        AND m.end_time > $2::time
+		  )
+		ORDER BY r.capacity NULLS LAST, r.building_code, r.room_number;`
+
+	return s.queryRooms(ctx, query, day, start, end, minCapacity)
+}
+
+func (s *Storage) UpdateRoom(ctx context.Context, roomID int, req *domain.UpdateRoomRequest) (*domain.Room, error) {
+	const query = `
+		UPDATE rooms r
+		SET capacity = COALESCE($2, r.capacity),
+		    features = COALESCE($3, r.features)
+		WHERE r.id = $1
+		RETURNING ` + roomColumns + `;`
+
+	return scanRoom(s.pool.QueryRow(ctx, query, roomID, req.Capacity, req.Features))
+}
@@ -3,13 +3,21 @@ package postgres
 import (
 	"context"
 	"scheduler/internal/domain"
+	"scheduler/internal/utils"
+
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// execer is satisfied by both the pool and a transaction
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
 func (s *Storage) CreateSchedule(ctx context.Context, studentID int, req *domain.CreateScheduleRequest) (*domain.Schedule, error) {
 	const query = `
 		INSERT INTO schedules (student_id, schedule_name, description)
         VALUES ($1, $2, $3)
-        RETURNING id, student_id, schedule_name, description, is_submitted, created_at;
+        RETURNING id, student_id, schedule_name, description, is_submitted, status, created_at;
 	`
 
 	var schedule domain.Schedule
@@ -19,6 +27,7 @@ func (s *Storage) CreateSchedule(ctx context.Context, studentID int, req *domain
 		&schedule.ScheduleName,
 		&schedule.Description,
 		&schedule.IsSubmitted,
+		&schedule.Status,
 		&schedule.CreatedAt,
 	)
 
@@ -27,7 +36,7 @@ func (s *Storage) CreateSchedule(ctx context.Context, studentID int, req *domain
 
 func (s *Storage) GetStudentSchedules(ctx context.Context, studentID int) ([]domain.Schedule, error) {
 	const query = `
-		SELECT id, student_id, schedule_name, description, is_submitted, created_at
+		SELECT id, student_id, schedule_name, description, is_submitted, status, created_at
         FROM schedules
         WHERE student_id = $1
         ORDER BY created_at DESC;
@@ -48,6 +57,7 @@ func (s *Storage) GetStudentSchedules(ctx context.Context, studentID int) ([]dom
 			&sch.ScheduleName,
 			&sch.Description,
 			&sch.IsSubmitted,
+			&sch.Status,
 			&sch.CreatedAt,
 		)
 		if err != nil {
@@ -61,12 +71,16 @@ func (s *Storage) GetStudentSchedules(ctx context.Context, studentID int) ([]dom
 }
 
 func (s *Storage) AddSectionToSchedule(ctx context.Context, scheduleID, sectionID int, meetingID *int) error {
+	return addSectionToSchedule(ctx, s.pool, scheduleID, sectionID, meetingID)
+}
+
+func addSectionToSchedule(ctx context.Context, db execer, scheduleID, sectionID int, meetingID *int) error {
 	const query = `
         INSERT INTO schedule_sections (schedule_id, section_id, meeting_id)
         VALUES ($1, $2, $3)
         ON CONFLICT (schedule_id, section_id, meeting_id) DO NOTHING;
     `
-	_, err := s.pool.Exec(ctx, query, scheduleID, sectionID, meetingID)
+	_, err := db.Exec(ctx, query, scheduleID, sectionID, meetingID)
 	return err
 }
 
@@ -76,25 +90,46 @@ func (s *Storage) RemoveSectionFromSchedule(ctx context.Context, scheduleID, sec
 	return err
 }
 
-func (s *Storage) SubmitSchedule(ctx context.Context, scheduleID int) error {
-	const query = `UPDATE schedules SET is_submitted = TRUE WHERE id = $1;`
-	_, err := s.pool.Exec(ctx, query, scheduleID)
-	return err
+// UpdateScheduleStatus moves a schedule to a new status, returning
+// utils.ErrInvalidTransition if its current status does not allow it
+func (s *Storage) UpdateScheduleStatus(ctx context.Context, scheduleID int, status string) error {
+	return updateScheduleStatus(ctx, s.pool, scheduleID, status)
+}
+
+func updateScheduleStatus(ctx context.Context, db execer, scheduleID int, status string) error {
+	const query = `
+		UPDATE schedules
+		SET status = $2, is_submitted = $3
+		WHERE id = $1 AND status = ANY($4);`
+
+	isSubmitted := status != domain.ScheduleDraft && status != domain.ScheduleChangesRequested
+	tag, err := db.Exec(ctx, query, scheduleID, status, isSubmitted, domain.StatusesTransitioningTo(status))
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return utils.ErrInvalidTransition
+	}
+
+	return nil
 }
 
 func (s *Storage) GetScheduleWithSections(ctx context.Context, scheduleID int) (*domain.ScheduleWithSections, error) {
 	var schedule domain.Schedule
 
-	const query = `SELECT id, student_id, schedule_name, description, is_submitted, created_at FROM schedules WHERE id = $1;`
+	const query = `SELECT id, student_id, schedule_name, description, is_submitted, status, created_at FROM schedules WHERE id = $1;`
 
 	const query2 = `
 		SELECT s.id, s.course_id, s.section_number, s.section_type,
                s.professor_id, s.total_seats, s.available_seats, s.parent_section_id,
-               c.course_code, c.course_name, c.credits,
+               c.id, c.course_code, c.course_name, c.credits,
+               p.first_name, p.last_name, p.email, p.rating,
                ss.meeting_id
         FROM schedule_sections ss
         JOIN sections s ON ss.section_id = s.id
         JOIN courses c ON s.course_id = c.id
+        LEFT JOIN professors p ON s.professor_id = p.id
         WHERE ss.schedule_id = $1;`
 
 	err := s.pool.QueryRow(ctx, query, scheduleID).Scan(
@@ -103,6 +138,7 @@ func (s *Storage) GetScheduleWithSections(ctx context.Context, scheduleID int) (
 		&schedule.ScheduleName,
 		&schedule.Description,
 		&schedule.IsSubmitted,
+		&schedule.Status,
 		&schedule.CreatedAt,
 	)
 
@@ -123,6 +159,8 @@ func (s *Storage) GetScheduleWithSections(ctx context.Context, scheduleID int) (
 	for rows.Next() {
 		var sd domain.SectionWithDetails
 		var meetingID *int
+		var profFirstName, profLastName *string
+		var prof domain.Professor
 		err := rows.Scan(
 			&sd.ID,
 			&sd.CourseID,
@@ -132,15 +170,27 @@ func (s *Storage) GetScheduleWithSections(ctx context.Context, scheduleID int) (
 			&sd.TotalSeats,
 			&sd.AvailableSeats,
 			&sd.ParentSectionID,
+			&sd.Course.ID,
 			&sd.Course.CourseCode,
 			&sd.Course.CourseName,
 			&sd.Course.Credits,
+			&profFirstName,
+			&profLastName,
+			&prof.Email,
+			&prof.Rating,
 			&meetingID,
 		)
 		if err != nil {
 			return nil, err
 		}
 
+		if sd.ProfessorID != nil && profFirstName != nil && profLastName != nil {
+			prof.ID = *sd.ProfessorID
+			prof.FirstName = *profFirstName
+			prof.LastName = *profLastName
+			sd.Professor = &prof
+		}
+
 		if meetingID != nil {
 			meeting, err := s.GetMeetingByID(ctx, *meetingID)
 			if err == nil {
@@ -164,9 +214,92 @@ func (s *Storage) GetScheduleWithSections(ctx context.Context, scheduleID int) (
 		}
 	}
 
+	blocks, err := s.GetScheduleBlocks(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
 	return &domain.ScheduleWithSections{
 		Schedule:     schedule,
 		Sections:     sections,
+		Blocks:       blocks,
 		TotalCredits: totalCredits,
 	}, nil
 }
+
+// UpdateSchedule changes the name and/or description; nil fields are left as is
+func (s *Storage) UpdateSchedule(ctx context.Context, scheduleID int, req *domain.UpdateScheduleRequest) (*domain.Schedule, error) {
+	const query = `
+		UPDATE schedules
+		SET schedule_name = COALESCE($2, schedule_name),
+		    description = CASE WHEN $3 THEN $4 ELSE description END
+		WHERE id = $1
+		RETURNING id, student_id, schedule_name, description, is_submitted, status, created_at;`
+
+	var schedule domain.Schedule
+	err := s.pool.QueryRow(ctx, query, scheduleID, req.ScheduleName, req.Description.Set, req.Description.Value).Scan(
+		&schedule.ID,
+		&schedule.StudentID,
+		&schedule.ScheduleName,
+		&schedule.Description,
+		&schedule.IsSubmitted,
+		&schedule.Status,
+		&schedule.CreatedAt,
+	)
+
+	return &schedule, err
+}
+
+func (s *Storage) DeleteSchedule(ctx context.Context, scheduleID int) error {
+	const query = `DELETE FROM schedules WHERE id = $1;`
+	_, err := s.pool.Exec(ctx, query, scheduleID)
+	return err
+}
+
+// CloneSchedule copies a schedule, all of its schedule_sections rows
+// (including chosen meetings) and its busy blocks into a new draft owned by
+// studentID
+func (s *Storage) CloneSchedule(ctx context.Context, scheduleID, studentID int, name string) (*domain.Schedule, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	const query = `
+		INSERT INTO schedules (student_id, schedule_name, description)
+		SELECT $2, $3, description FROM schedules WHERE id = $1
+		RETURNING id, student_id, schedule_name, description, is_submitted, status, created_at;`
+
+	var schedule domain.Schedule
+	err = tx.QueryRow(ctx, query, scheduleID, studentID, name).Scan(
+		&schedule.ID,
+		&schedule.StudentID,
+		&schedule.ScheduleName,
+		&schedule.Description,
+		&schedule.IsSubmitted,
+		&schedule.Status,
+		&schedule.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	const copyQuery = `
+		INSERT INTO schedule_sections (schedule_id, section_id, meeting_id)
+		SELECT $2, section_id, meeting_id FROM schedule_sections WHERE schedule_id = $1;`
+
+	if _, err := tx.Exec(ctx, copyQuery, scheduleID, schedule.ID); err != nil {
+		return nil, err
+	}
+
+	const copyBlocksQuery = `
+		INSERT INTO schedule_blocks (schedule_id, label, days, start_time, end_time, location)
+		SELECT $2, label, days, start_time, end_time, location FROM schedule_blocks WHERE schedule_id = $1;`
+
+	if _, err := tx.Exec(ctx, copyBlocksQuery, scheduleID, schedule.ID); err != nil {
+		return nil, err
+	}
+
+	return &schedule, tx.Commit(ctx)
+}
@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"scheduler/internal/domain"
+)
+
+// seatChannel is the NOTIFY channel the notify_seat_change trigger publishes to
+const seatChannel = "seat_changes"
+
+func (s *Storage) GetSeatCounts(ctx context.Context, sectionIDs []int) ([]domain.SeatUpdate, error) {
+	const query = `
+		SELECT id, available_seats, total_seats
+		FROM sections
+		WHERE id = ANY($1)
+		ORDER BY id;`
+
+	rows, err := s.pool.Query(ctx, query, sectionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	updates := []domain.SeatUpdate{}
+	for rows.Next() {
+		var u domain.SeatUpdate
+		if err := rows.Scan(&u.SectionID, &u.AvailableSeats, &u.TotalSeats); err != nil {
+			return nil, err
+		}
+		updates = append(updates, u)
+	}
+
+	return updates, rows.Err()
+}
+
+// ListenSeatChanges holds a dedicated connection listening for seat count
+// changes and calls handle for each one. It blocks until ctx is cancelled or
+// the connection fails.
+func (s *Storage) ListenSeatChanges(ctx context.Context, handle func(domain.SeatUpdate)) error {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The connection stays in LISTEN mode, so take it out of the pool for good
+	listenConn := conn.Hijack()
+	defer listenConn.Close(context.Background())
+
+	if _, err := listenConn.Exec(ctx, "LISTEN "+seatChannel); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := listenConn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var update domain.SeatUpdate
+		if err := json.Unmarshal([]byte(notification.Payload), &update); err != nil {
+			continue
+		}
+		handle(update)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/csv"
 	"fmt"
 	"log"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -37,6 +38,9 @@ type MeetingInfo struct {
 	End      string
 	Building *string
 	Room     *string
+	Capacity int
+	// Location is the room column as written, e.g. "online" or "TBA"
+	Location string
 }
 
 type SectionInfo struct {
@@ -48,6 +52,7 @@ type SectionInfo struct {
 	Semester     string
 	ProfessorIDs []int
 	TotalSeats   int
+	Enrolled     int
 	Meetings     map[string]MeetingInfo
 }
 
@@ -64,8 +69,23 @@ func (s *Storage) SeedDatabase(ctx context.Context) error {
 	}
 
 	log.Println("Starting database seeding...")
+	return s.importCatalog(ctx, csvData)
+}
+
+// RefreshCatalog re-imports the course data over the existing catalog.
+// Courses, sections and meetings are updated in place, so schedules and
+// watchlists keep pointing at the same rows, and a section whose
+// available_seats rise above zero queues a seat opening for its watchers.
+// Meetings dropped from the data are not removed.
+func (s *Storage) RefreshCatalog(ctx context.Context) error {
+	log.Println("Refreshing course data...")
+	return s.importCatalog(ctx, csvData)
+}
 
-	reader := csv.NewReader(strings.NewReader(csvData))
+// importCatalog upserts the courses, sections and meetings of a schedule
+// export in the school_schedule_by_term.csv format
+func (s *Storage) importCatalog(ctx context.Context, data string) error {
+	reader := csv.NewReader(strings.NewReader(data))
 	records, err := reader.ReadAll()
 	if err != nil {
 		return fmt.Errorf("failed to parse CSV: %w", err)
@@ -81,6 +101,7 @@ func (s *Storage) SeedDatabase(ctx context.Context) error {
 	professorMap := make(map[string]int)
 	courseMap := make(map[string]int)
 	sectionMap := make(map[string]*SectionInfo)
+	var invalidTimes []string
 	courseCredits := make(map[string]float64)
 	courseCreditSource := make(map[string]string)
 
@@ -137,6 +158,7 @@ func (s *Storage) SeedDatabase(ctx context.Context) error {
 				Semester:     semester,
 				ProfessorIDs: professorIDs,
 				TotalSeats:   data.Cap,
+				Enrolled:     data.Enr,
 				Meetings:     make(map[string]MeetingInfo),
 			}
 		}
@@ -147,6 +169,14 @@ func (s *Storage) SeedDatabase(ctx context.Context) error {
 			daysList := parseDays(data.Days)
 			startTime, endTime := parseTime(data.Time)
 			building, roomNum := parseRoom(data.Room)
+			capacity := parseRoomCapacity(data.Room)
+
+			// section_meetings requires end_time > start_time, so these
+			// cannot be imported; they are listed once seeding is done
+			if startTime != "" && endTime != "" && endTime <= startTime {
+				invalidTimes = append(invalidTimes, fmt.Sprintf("%s-%s %s %s", courseCode, sectionNum, data.Days, data.Time))
+				continue
+			}
 
 			for _, day := range daysList {
 				meetingKey := day + "|" + startTime + "|" + endTime
@@ -157,6 +187,8 @@ func (s *Storage) SeedDatabase(ctx context.Context) error {
 						End:      endTime,
 						Building: building,
 						Room:     roomNum,
+						Capacity: capacity,
+						Location: roomLocation(data.Room),
 					}
 				}
 			}
@@ -187,7 +219,7 @@ func (s *Storage) SeedDatabase(ctx context.Context) error {
 			professorID = &section.ProfessorIDs[0]
 		}
 
-		sectionID, err := s.insertSection(ctx, courseID, section.SectionNum, section.SectionType, professorID, section.TotalSeats)
+		sectionID, err := s.insertSection(ctx, courseID, section.SectionNum, section.SectionType, professorID, section.TotalSeats, section.Enrolled)
 		if err != nil {
 			log.Printf("Failed to insert section %s-%s: %v", section.CourseCode, section.SectionNum, err)
 			continue
@@ -202,6 +234,19 @@ func (s *Storage) SeedDatabase(ctx context.Context) error {
 	log.Printf("Seeding complete: %d courses, %d sections, %d professors",
 		coursesAdded, sectionsAdded, len(professorMap))
 
+	if len(invalidTimes) > 0 {
+		log.Printf("Skipped %d meetings that end before they start: %s",
+			len(invalidTimes), strings.Join(invalidTimes, "; "))
+	}
+
+	report, err := s.GetCatalogIntegrityReport(ctx)
+	if err != nil {
+		log.Printf("Failed to check catalog integrity: %v", err)
+	} else if len(report.Issues) > 0 {
+		log.Printf("Catalog integrity: %d errors, %d warnings %v (see /api/admin/reports/catalog-integrity)",
+			report.Errors, report.Warnings, report.Counts)
+	}
+
 	return nil
 }
 
@@ -261,21 +306,77 @@ func (s *Storage) insertProfessors(ctx context.Context, faculty string, profMap
 	return []int{id}
 }
 
-func (s *Storage) insertSection(ctx context.Context, courseID int, sectionNum, sectionType string, professorID *int, totalSeats int) (int, error) {
+// insertSection upserts a section with the seats left after enrolled, so a
+// re-import that frees seats in a full section fires record_seat_opening
+func (s *Storage) insertSection(ctx context.Context, courseID int, sectionNum, sectionType string, professorID *int, totalSeats, enrolled int) (int, error) {
 	var id int
 	query := `INSERT INTO sections (course_id, section_number, section_type, professor_id, total_seats, available_seats)
-	          VALUES ($1, $2, $3, $4, $5, $5)
-	          ON CONFLICT (course_id, section_number) DO UPDATE SET total_seats = EXCLUDED.total_seats
+	          VALUES ($1, $2, $3, $4, $5, GREATEST($5 - $6, 0))
+	          ON CONFLICT (course_id, section_number) DO UPDATE
+	          SET section_type = EXCLUDED.section_type,
+	              professor_id = EXCLUDED.professor_id,
+	              total_seats = EXCLUDED.total_seats,
+	              available_seats = EXCLUDED.available_seats
 	          RETURNING id`
-	err := s.pool.QueryRow(ctx, query, courseID, sectionNum, sectionType, professorID, totalSeats).Scan(&id)
+	err := s.pool.QueryRow(ctx, query, courseID, sectionNum, sectionType, professorID, totalSeats, enrolled).Scan(&id)
+	return id, err
+}
+
+func (s *Storage) insertBuilding(ctx context.Context, code string) error {
+	query := `INSERT INTO buildings (code) VALUES ($1) ON CONFLICT (code) DO NOTHING`
+	_, err := s.pool.Exec(ctx, query, code)
+	return err
+}
+
+// insertRoom registers a room, keeping the largest capacity reported for it.
+// Rooms without a number (e.g. "Orange Hall") use an empty room_number.
+func (s *Storage) insertRoom(ctx context.Context, building string, roomNum *string, capacity int) (int, error) {
+	number := ""
+	if roomNum != nil {
+		number = *roomNum
+	}
+
+	var roomCapacity *int
+	if capacity > 0 {
+		roomCapacity = &capacity
+	}
+
+	var id int
+	query := `INSERT INTO rooms (building_code, room_number, capacity)
+	          VALUES ($1, $2, $3)
+	          ON CONFLICT (building_code, room_number) DO UPDATE
+	          SET capacity = GREATEST(rooms.capacity, EXCLUDED.capacity)
+	          RETURNING id`
+	err := s.pool.QueryRow(ctx, query, building, number, roomCapacity).Scan(&id)
 	return id, err
 }
 
 func (s *Storage) insertSectionMeeting(ctx context.Context, sectionID int, meeting MeetingInfo) {
-	query := `INSERT INTO section_meetings (section_id, day_of_week, start_time, end_time, room, building)
-	          VALUES ($1, $2, $3, $4, $5, $6)
-	          ON CONFLICT DO NOTHING`
-	_, err := s.pool.Exec(ctx, query, sectionID, meeting.Day, meeting.Start, meeting.End, meeting.Room, meeting.Building)
+	var roomID *int
+	if meeting.Building != nil {
+		if err := s.insertBuilding(ctx, *meeting.Building); err != nil {
+			log.Printf("Failed to insert building %s: %v", *meeting.Building, err)
+		}
+
+		id, err := s.insertRoom(ctx, *meeting.Building, meeting.Room, meeting.Capacity)
+		if err != nil {
+			log.Printf("Failed to insert room in %s: %v", *meeting.Building, err)
+		} else {
+			roomID = &id
+		}
+	}
+
+	// section_meetings has no unique key to upsert on, so a meeting already
+	// imported for the section at the same time is updated instead
+	query := `WITH updated AS (
+	              UPDATE section_meetings SET room = $5, building = $6, room_id = $7, location = NULLIF($8, '')
+	              WHERE section_id = $1 AND day_of_week = $2 AND start_time = $3::time AND end_time = $4::time
+	              RETURNING id
+	          )
+	          INSERT INTO section_meetings (section_id, day_of_week, start_time, end_time, room, building, room_id, location)
+	          SELECT $1, $2, $3::time, $4::time, $5, $6, $7, NULLIF($8, '')
+	          WHERE NOT EXISTS (SELECT 1 FROM updated)`
+	_, err := s.pool.Exec(ctx, query, sectionID, meeting.Day, meeting.Start, meeting.End, meeting.Room, meeting.Building, roomID, meeting.Location)
 	if err != nil {
 		log.Printf("Failed to insert meeting for section %d: %v", sectionID, err)
 	}
@@ -365,18 +466,17 @@ func convertTo24Hour(t string) string {
 	return parsed.Format("15:04:05")
 }
 
+// dottedRoomPattern matches rooms written as "<building>.<room>", e.g. "7E.329"
+var dottedRoomPattern = regexp.MustCompile(`^(\d+[A-Za-z]?)\.(\S+)$`)
+
 func parseRoom(room string) (building, roomNum *string) {
-	if room == "" {
-		return nil, nil
-	}
+	location := roomLocation(room)
 
-	parts := strings.Split(room, "-")
-	if len(parts) == 0 {
+	switch strings.ToLower(location) {
+	case "", "online", "tba":
 		return nil, nil
 	}
 
-	location := strings.TrimSpace(parts[0])
-
 	if strings.Contains(location, "(") && strings.Contains(location, ")") {
 		start := strings.Index(location, "(")
 		end := strings.Index(location, ")")
@@ -386,9 +486,29 @@ func parseRoom(room string) (building, roomNum *string) {
 		return &buildingCode, &room
 	}
 
+	if match := dottedRoomPattern.FindStringSubmatch(location); match != nil {
+		return &match[1], &match[2]
+	}
+
 	return &location, nil
 }
 
+// roomLocation returns the room column without its " - cap:N" suffix
+func roomLocation(room string) string {
+	location, _, _ := strings.Cut(room, "-")
+	return strings.TrimSpace(location)
+}
+
+// parseRoomCapacity extracts N from a "... - cap:N" room column, returning 0
+// if there is none
+func parseRoomCapacity(room string) int {
+	idx := strings.LastIndex(room, "cap:")
+	if idx < 0 {
+		return 0
+	}
+	return parseInt(room[idx+len("cap:"):])
+}
+
 func parseCredits(s string) float64 {
 	s = strings.TrimSpace(s)
 	val, _ := strconv.ParseFloat(s, 64)
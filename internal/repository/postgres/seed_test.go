@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"scheduler/internal/domain"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testStorage connects to TEST_DATABASE_URL and applies the schema, skipping
+// the test when it is not set
+func testStorage(t *testing.T) *Storage {
+	t.Helper()
+
+	connString := os.Getenv("TEST_DATABASE_URL")
+	if connString == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	storage, err := NewConnection(connString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(storage.Close)
+
+	if err := storage.RunMigrations(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return storage
+}
+
+// catalogCSV builds a one-section export in the school_schedule_by_term.csv
+// format
+func catalogCSV(courseCode string, enrolled, capacity int) string {
+	return strings.Join([]string{
+		"Spring 2026,,,,,,,,,,,,,,",
+		"2026-02-01 11:29:24,,,,,,,,,,,,,,",
+		"School,Level,Course Abbr,S/T,Course Title,Cr(US),Cr(ECTS),Start date,End date,Days,Time,Enr,Cap,Faculty,Room",
+		fmt.Sprintf("SEDS,UG,%s,1L,Import Test,3.0,6,12-JAN-26,24-APR-26,M ,09:00 AM-10:15 AM,%d,%d,,Online", courseCode, enrolled, capacity),
+	}, "\n")
+}
+
+func TestRefreshCatalogNotifiesWatchers(t *testing.T) {
+	storage := testStorage(t)
+	ctx := context.Background()
+
+	suffix := fmt.Sprintf("%d", time.Now().UnixNano()%1e9)
+	courseCode := "IMP " + suffix
+
+	if err := storage.importCatalog(ctx, catalogCSV(courseCode, 30, 30)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		storage.pool.Exec(context.Background(), `DELETE FROM courses WHERE course_code = $1`, courseCode)
+	})
+
+	var sectionID, available int
+	err := storage.pool.QueryRow(ctx, `
+		SELECT s.id, s.available_seats
+		FROM sections s JOIN courses c ON c.id = s.course_id
+		WHERE c.course_code = $1`, courseCode).Scan(&sectionID, &available)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if available != 0 {
+		t.Fatalf("available seats = %d, want 0 for a full section", available)
+	}
+
+	student, err := storage.CreateStudent(ctx, &domain.RegisterRequest{
+		Email:       "import-" + suffix + "@nu.edu.kz",
+		FirstName:   "Test",
+		LastName:    "Student",
+		StudentID:   "i" + suffix,
+		YearOfStudy: 1,
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { storage.DeleteStudent(context.Background(), student.ID) })
+
+	if _, err := storage.AddToWatchlist(ctx, student.ID, &domain.WatchRequest{SectionID: &sectionID}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two students dropped the course since the last export
+	if err := storage.importCatalog(ctx, catalogCSV(courseCode, 28, 30)); err != nil {
+		t.Fatal(err)
+	}
+
+	var updatedID int
+	err = storage.pool.QueryRow(ctx, `
+		SELECT s.id, s.available_seats
+		FROM sections s JOIN courses c ON c.id = s.course_id
+		WHERE c.course_code = $1`, courseCode).Scan(&updatedID, &available)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updatedID != sectionID {
+		t.Fatalf("section id = %d after re-import, want %d", updatedID, sectionID)
+	}
+	if available != 2 {
+		t.Fatalf("available seats = %d, want 2", available)
+	}
+
+	var meetings int
+	err = storage.pool.QueryRow(ctx, `SELECT COUNT(*) FROM section_meetings WHERE section_id = $1`, sectionID).Scan(&meetings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meetings != 1 {
+		t.Fatalf("section has %d meetings after re-import, want 1", meetings)
+	}
+
+	deliveries, err := storage.ProcessSeatOpenings(ctx, 60, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var found bool
+	for _, d := range deliveries {
+		if d.StudentID == student.ID && d.SectionID != nil && *d.SectionID == sectionID {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("no seat_available notification for student %d in %+v", student.ID, deliveries)
+	}
+}
@@ -0,0 +1,268 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"scheduler/internal/domain"
+	"scheduler/internal/utils"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const sessionColumns = `id, student_id, user_agent, ip_address, created_at, last_used_at, expires_at, revoked_at, impersonator_id`
+
+func scanSession(row pgx.Row) (*domain.Session, error) {
+	var s domain.Session
+	err := row.Scan(
+		&s.ID,
+		&s.StudentID,
+		&s.UserAgent,
+		&s.IPAddress,
+		&s.CreatedAt,
+		&s.LastUsedAt,
+		&s.ExpiresAt,
+		&s.RevokedAt,
+		&s.ImpersonatorID,
+	)
+	return &s, err
+}
+
+// CreateSession starts a login session together with its first refresh token
+func (s *Storage) CreateSession(ctx context.Context, studentID int, userAgent, ipAddress, refreshHash string, expiresAt time.Time) (*domain.Session, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	const query = `
+		INSERT INTO sessions (student_id, user_agent, ip_address, expires_at)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4)
+		RETURNING ` + sessionColumns + `;`
+
+	session, err := scanSession(tx.QueryRow(ctx, query, studentID, userAgent, ipAddress, expiresAt))
+	if err != nil {
+		return nil, err
+	}
+
+	const tokenQuery = `INSERT INTO refresh_tokens (session_id, token_hash, expires_at) VALUES ($1, $2, $3);`
+	if _, err := tx.Exec(ctx, tokenQuery, session.ID, refreshHash, expiresAt); err != nil {
+		return nil, err
+	}
+
+	// Each login clears out a batch of sessions that ended long ago, along
+	// with their refresh tokens, so the tables do not grow without bound
+	const cleanupQuery = `
+		DELETE FROM sessions
+		WHERE id IN (
+		    SELECT id FROM sessions
+		    WHERE expires_at < NOW() - make_interval(days => $1)
+		       OR revoked_at < NOW() - make_interval(days => $1)
+		    LIMIT 100
+		);`
+	if _, err := tx.Exec(ctx, cleanupQuery, endedSessionRetentionDays); err != nil {
+		return nil, err
+	}
+
+	return session, tx.Commit(ctx)
+}
+
+// endedSessionRetentionDays is how long revoked and expired sessions stay
+// listed in the data export before CreateSession deletes them
+const endedSessionRetentionDays = 30
+
+// CreateImpersonationSession starts a session in which impersonatorID acts
+// as the student. It has no refresh token, so it ends at expiresAt.
+func (s *Storage) CreateImpersonationSession(ctx context.Context, studentID, impersonatorID int, userAgent, ipAddress string, expiresAt time.Time) (*domain.Session, error) {
+	const query = `
+		INSERT INTO sessions (student_id, impersonator_id, user_agent, ip_address, expires_at)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5)
+		RETURNING ` + sessionColumns + `;`
+
+	return scanSession(s.pool.QueryRow(ctx, query, studentID, impersonatorID, userAgent, ipAddress, expiresAt))
+}
+
+// RotateRefreshToken exchanges a refresh token for a new one in the same
+// session and extends the session to expiresAt, but never past maxAge after
+// the login. Presenting a token that was already rotated means it leaked, so
+// the whole session is revoked and utils.ErrTokenReused returned.
+func (s *Storage) RotateRefreshToken(ctx context.Context, oldHash, newHash string, expiresAt time.Time, maxAge time.Duration) (*domain.Session, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	const findQuery = `
+		SELECT rt.id, rt.session_id, rt.used_at IS NOT NULL, rt.expires_at < NOW(), s.revoked_at IS NOT NULL
+		FROM refresh_tokens rt
+		JOIN sessions s ON rt.session_id = s.id
+		WHERE rt.token_hash = $1
+		FOR UPDATE OF rt;`
+
+	var tokenID, sessionID int
+	var used, expired, revoked bool
+	err = tx.QueryRow(ctx, findQuery, oldHash).Scan(&tokenID, &sessionID, &used, &expired, &revoked)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, utils.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if used {
+		// Commit the revocation even though the request fails
+		if err := revokeSession(ctx, tx, sessionID); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return nil, err
+		}
+		return nil, utils.ErrTokenReused
+	}
+
+	if revoked {
+		return nil, utils.ErrInvalidToken
+	}
+
+	if expired {
+		return nil, utils.ErrExpiredToken
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET used_at = NOW() WHERE id = $1;`, tokenID); err != nil {
+		return nil, err
+	}
+
+	const sessionQuery = `
+		UPDATE sessions
+		SET last_used_at = NOW(), expires_at = LEAST($2::timestamp, created_at + make_interval(secs => $3))
+		WHERE id = $1
+		RETURNING ` + sessionColumns + `;`
+
+	session, err := scanSession(tx.QueryRow(ctx, sessionQuery, sessionID, expiresAt, maxAge.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+
+	// The new token dies with the session; expired ones are no longer needed
+	// to detect reuse
+	const tokenQuery = `INSERT INTO refresh_tokens (session_id, token_hash, expires_at) VALUES ($1, $2, $3);`
+	if _, err := tx.Exec(ctx, tokenQuery, sessionID, newHash, session.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	const cleanupQuery = `DELETE FROM refresh_tokens WHERE session_id = $1 AND expires_at < NOW();`
+	if _, err := tx.Exec(ctx, cleanupQuery, sessionID); err != nil {
+		return nil, err
+	}
+
+	return session, tx.Commit(ctx)
+}
+
+func revokeSession(ctx context.Context, db execer, sessionID int) error {
+	const query = `UPDATE sessions SET revoked_at = COALESCE(revoked_at, NOW()) WHERE id = $1;`
+	_, err := db.Exec(ctx, query, sessionID)
+	return err
+}
+
+// RevokeSession ends a session; its refresh tokens stop working and its
+// access tokens are rejected by JWTAuth
+func (s *Storage) RevokeSession(ctx context.Context, sessionID int) error {
+	return revokeSession(ctx, s.pool, sessionID)
+}
+
+// RevokeAccessToken adds a jti to the revocation list until the token would
+// have expired anyway, clearing out entries that no longer matter
+func (s *Storage) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	const query = `
+		WITH cleanup AS (DELETE FROM revoked_tokens WHERE expires_at < NOW())
+		INSERT INTO revoked_tokens (jti, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING;`
+
+	_, err := s.pool.Exec(ctx, query, jti, expiresAt)
+	return err
+}
+
+// IsTokenRevoked reports whether an access token was revoked by jti or
+// belongs to a revoked or expired session
+func (s *Storage) IsTokenRevoked(ctx context.Context, jti string, sessionID int) (bool, error) {
+	const query = `
+		SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)
+		    OR NOT EXISTS (
+		        SELECT 1 FROM sessions
+		        WHERE id = $2 AND revoked_at IS NULL AND expires_at > NOW()
+		    );`
+
+	var revoked bool
+	err := s.pool.QueryRow(ctx, query, jti, sessionID).Scan(&revoked)
+	return revoked, err
+}
+
+// GetActiveSessions lists a student's unrevoked, unexpired sessions, most
+// recently used first
+func (s *Storage) GetActiveSessions(ctx context.Context, studentID int) ([]domain.Session, error) {
+	const query = `
+		SELECT ` + sessionColumns + `
+		FROM sessions
+		WHERE student_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_used_at DESC;`
+
+	rows, err := s.pool.Query(ctx, query, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	sessions := []domain.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// RevokeStudentSession revokes one of a student's sessions; it returns
+// pgx.ErrNoRows if the session is not theirs or already ended
+func (s *Storage) RevokeStudentSession(ctx context.Context, studentID, sessionID int) error {
+	const query = `
+		UPDATE sessions
+		SET revoked_at = NOW()
+		WHERE id = $1 AND student_id = $2 AND revoked_at IS NULL;`
+
+	tag, err := s.pool.Exec(ctx, query, sessionID, studentID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+// RevokeOtherSessions revokes every session of the student except keep; pass
+// 0 to revoke them all. It returns how many sessions were revoked.
+func (s *Storage) RevokeOtherSessions(ctx context.Context, studentID, keep int) (int64, error) {
+	return revokeOtherSessions(ctx, s.pool, studentID, keep)
+}
+
+func revokeOtherSessions(ctx context.Context, db execer, studentID, keep int) (int64, error) {
+	const query = `
+		UPDATE sessions
+		SET revoked_at = NOW()
+		WHERE student_id = $1 AND id <> $2 AND revoked_at IS NULL;`
+
+	tag, err := db.Exec(ctx, query, studentID, keep)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
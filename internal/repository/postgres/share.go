@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"scheduler/internal/domain"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const shareColumns = `id, schedule_id, token, display_name, expires_at, revoked_at, created_at`
+
+func scanShare(row pgx.Row) (*domain.ScheduleShare, error) {
+	var sh domain.ScheduleShare
+	err := row.Scan(
+		&sh.ID,
+		&sh.ScheduleID,
+		&sh.Token,
+		&sh.DisplayName,
+		&sh.ExpiresAt,
+		&sh.RevokedAt,
+		&sh.CreatedAt,
+	)
+	return &sh, err
+}
+
+func (s *Storage) CreateScheduleShare(ctx context.Context, scheduleID int, token, displayName string, expiresAt *time.Time) (*domain.ScheduleShare, error) {
+	const query = `
+		INSERT INTO schedule_shares (schedule_id, token, display_name, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + shareColumns + `;`
+
+	return scanShare(s.pool.QueryRow(ctx, query, scheduleID, token, displayName, expiresAt))
+}
+
+func (s *Storage) GetShareByToken(ctx context.Context, token string) (*domain.ScheduleShare, error) {
+	const query = `SELECT ` + shareColumns + ` FROM schedule_shares WHERE token = $1;`
+	return scanShare(s.pool.QueryRow(ctx, query, token))
+}
+
+func (s *Storage) GetScheduleShares(ctx context.Context, scheduleID int) ([]domain.ScheduleShare, error) {
+	const query = `
+		SELECT ` + shareColumns + `
+		FROM schedule_shares
+		WHERE schedule_id = $1
+		ORDER BY created_at DESC;`
+
+	rows, err := s.pool.Query(ctx, query, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	var shares []domain.ScheduleShare
+	for rows.Next() {
+		sh, err := scanShare(rows)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, *sh)
+	}
+
+	return shares, rows.Err()
+}
+
+// RevokeScheduleShare revokes a link; it returns pgx.ErrNoRows if the link
+// does not belong to the schedule
+func (s *Storage) RevokeScheduleShare(ctx context.Context, scheduleID, shareID int) error {
+	const query = `
+		UPDATE schedule_shares
+		SET revoked_at = COALESCE(revoked_at, NOW())
+		WHERE id = $1 AND schedule_id = $2;`
+
+	tag, err := s.pool.Exec(ctx, query, shareID, scheduleID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"scheduler/internal/utils"
+)
+
+// RotateSigningKeys drops expired signing keys, lets next create a successor
+// when needed and returns every remaining key. next receives the newest key
+// (nil if there is none) and returns the key to add, or nil. Instances
+// rotate under an advisory lock so only one of them creates the successor.
+func (s *Storage) RotateSigningKeys(ctx context.Context, next func(latest *utils.SigningKey) (*utils.SigningKey, error)) ([]*utils.SigningKey, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('signing_keys'));`); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM signing_keys WHERE expires_at < NOW();`); err != nil {
+		return nil, err
+	}
+
+	const query = `
+		SELECT kid, algorithm, private_key, activates_at, retires_at, expires_at
+		FROM signing_keys
+		ORDER BY activates_at;`
+
+	rows, err := tx.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	type sealedKey struct {
+		key    utils.SigningKey
+		sealed []byte
+	}
+	var sealed []sealedKey
+	for rows.Next() {
+		var k sealedKey
+		if err := rows.Scan(&k.key.Kid, &k.key.Algorithm, &k.sealed, &k.key.ActivatesAt, &k.key.RetiresAt, &k.key.ExpiresAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		sealed = append(sealed, k)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	keys := make([]*utils.SigningKey, 0, len(sealed)+1)
+	for _, k := range sealed {
+		key := k.key
+		if key.Private, err = utils.OpenPrivateKey(k.sealed); err != nil {
+			return nil, err
+		}
+		keys = append(keys, &key)
+	}
+
+	var latest *utils.SigningKey
+	if len(keys) > 0 {
+		latest = keys[len(keys)-1]
+	}
+
+	successor, err := next(latest)
+	if err != nil {
+		return nil, err
+	}
+
+	if successor != nil {
+		privateKey, err := utils.SealPrivateKey(successor.Private)
+		if err != nil {
+			return nil, err
+		}
+
+		const insertQuery = `
+			INSERT INTO signing_keys (kid, algorithm, private_key, activates_at, retires_at, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6);`
+
+		_, err = tx.Exec(ctx, insertQuery, successor.Kid, successor.Algorithm, privateKey,
+			successor.ActivatesAt.UTC(), successor.RetiresAt.UTC(), successor.ExpiresAt.UTC())
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, successor)
+	}
+
+	return keys, tx.Commit(ctx)
+}
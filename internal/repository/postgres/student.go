@@ -3,29 +3,41 @@ package postgres
 import (
 	"context"
 	"scheduler/internal/domain"
+
+	"github.com/jackc/pgx/v5"
 )
 
+const studentColumns = `id, email, first_name, last_name, student_id, year_of_study,
+        total_credits_earned, role, standing, advisor_id, email_verified_at,
+        totp_enabled_at IS NOT NULL, two_factor_required, created_at`
+
+func scanStudent(row pgx.Row) (*domain.Student, error) {
+	var student domain.Student
+	err := row.Scan(
+		&student.ID, &student.Email, &student.FirstName, &student.LastName,
+		&student.StudentID, &student.YearOfStudy, &student.TotalCreditsEarned,
+		&student.Role, &student.Standing, &student.AdvisorID, &student.EmailVerifiedAt,
+		&student.TwoFactorEnabled, &student.TwoFactorRequired, &student.CreatedAt,
+	)
+	return &student, err
+}
+
 func (s *Storage) CreateStudent(ctx context.Context, req *domain.RegisterRequest, passwordHash string) (*domain.Student, error) {
 	const query = `
         INSERT INTO students (email, password_hash, first_name, last_name, student_id, year_of_study)
         VALUES ($1, $2, $3, $4, $5, $6)
-        RETURNING id, email, first_name, last_name, student_id, year_of_study, total_credits_earned, created_at;
-    `
+        RETURNING ` + studentColumns + `;`
 
-	var student domain.Student
-	err := s.pool.QueryRow(ctx, query,
+	return scanStudent(s.pool.QueryRow(ctx, query,
 		req.Email, passwordHash, req.FirstName, req.LastName, req.StudentID, req.YearOfStudy,
-	).Scan(
-		&student.ID, &student.Email, &student.FirstName, &student.LastName,
-		&student.StudentID, &student.YearOfStudy, &student.TotalCreditsEarned, &student.CreatedAt,
-	)
-
-	return &student, err
+	))
 }
 
 func (s *Storage) GetStudentByEmail(ctx context.Context, email string) (*domain.Student, error) {
 	const query = `
-        SELECT id, email, password_hash, first_name, last_name, student_id, year_of_study, total_credits_earned, created_at
+        SELECT id, email, password_hash, first_name, last_name, student_id, year_of_study,
+               total_credits_earned, role, standing, advisor_id, email_verified_at,
+               totp_enabled_at IS NOT NULL, two_factor_required, created_at
         FROM students WHERE email = $1;
     `
 
@@ -33,23 +45,101 @@ func (s *Storage) GetStudentByEmail(ctx context.Context, email string) (*domain.
 	err := s.pool.QueryRow(ctx, query, email).Scan(
 		&student.ID, &student.Email, &student.PasswordHash, &student.FirstName,
 		&student.LastName, &student.StudentID, &student.YearOfStudy,
-		&student.TotalCreditsEarned, &student.CreatedAt,
+		&student.TotalCreditsEarned, &student.Role, &student.Standing, &student.AdvisorID,
+		&student.EmailVerifiedAt, &student.TwoFactorEnabled, &student.TwoFactorRequired, &student.CreatedAt,
 	)
 
 	return &student, err
 }
 
 func (s *Storage) GetStudentByID(ctx context.Context, id int) (*domain.Student, error) {
+	const query = `SELECT ` + studentColumns + ` FROM students WHERE id = $1;`
+	return scanStudent(s.pool.QueryRow(ctx, query, id))
+}
+
+// UpdateStudentRole changes a student's role. An actual change logs the
+// student out everywhere, since access tokens carry the role they were
+// issued with.
+func (s *Storage) UpdateStudentRole(ctx context.Context, id int, role string) (*domain.Student, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var oldRole string
+	const current = `SELECT role FROM students WHERE id = $1 FOR UPDATE;`
+	if err := tx.QueryRow(ctx, current, id).Scan(&oldRole); err != nil {
+		return nil, err
+	}
+
+	const query = `UPDATE students SET role = $2 WHERE id = $1 RETURNING ` + studentColumns + `;`
+	student, err := scanStudent(tx.QueryRow(ctx, query, id, role))
+	if err != nil {
+		return nil, err
+	}
+
+	if role != oldRole {
+		if _, err := revokeOtherSessions(ctx, tx, id, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	return student, tx.Commit(ctx)
+}
+
+// AssignAdvisor sets or clears (advisorID == nil) a student's advisor
+func (s *Storage) AssignAdvisor(ctx context.Context, id int, advisorID *int) (*domain.Student, error) {
+	const query = `UPDATE students SET advisor_id = $2 WHERE id = $1 RETURNING ` + studentColumns + `;`
+	return scanStudent(s.pool.QueryRow(ctx, query, id, advisorID))
+}
+
+func (s *Storage) GetPasswordHash(ctx context.Context, id int) (string, error) {
+	var hash string
+	err := s.pool.QueryRow(ctx, `SELECT password_hash FROM students WHERE id = $1;`, id).Scan(&hash)
+	return hash, err
+}
+
+// UpdateProfile changes the fields of req that are set
+func (s *Storage) UpdateProfile(ctx context.Context, id int, req *domain.UpdateProfileRequest) (*domain.Student, error) {
 	const query = `
-        SELECT id, email, first_name, last_name, student_id, year_of_study, total_credits_earned, created_at
-        FROM students WHERE id = $1;
-    `
+		UPDATE students
+		SET first_name = COALESCE($2, first_name),
+		    last_name = COALESCE($3, last_name),
+		    year_of_study = COALESCE($4, year_of_study)
+		WHERE id = $1
+		RETURNING ` + studentColumns + `;`
 
-	var student domain.Student
-	err := s.pool.QueryRow(ctx, query, id).Scan(
-		&student.ID, &student.Email, &student.FirstName, &student.LastName,
-		&student.StudentID, &student.YearOfStudy, &student.TotalCreditsEarned, &student.CreatedAt,
-	)
+	return scanStudent(s.pool.QueryRow(ctx, query, id, req.FirstName, req.LastName, req.YearOfStudy))
+}
 
-	return &student, err
+// DeleteStudent removes the account. Schedules, sessions, watchlist,
+// notifications and the rest of the student's data go with it through
+// ON DELETE CASCADE; audit log entries are kept without the email. Study
+// groups the student owns pass to another member so they are not deleted
+// for everyone. It returns pgx.ErrNoRows if the student does not exist.
+func (s *Storage) DeleteStudent(ctx context.Context, id int) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `UPDATE audit_log SET email = NULL WHERE student_id = $1;`, id); err != nil {
+		return err
+	}
+
+	if err := handOverGroups(ctx, tx, id); err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, `DELETE FROM students WHERE id = $1;`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return tx.Commit(ctx)
 }
@@ -0,0 +1,244 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"scheduler/internal/domain"
+	"scheduler/internal/utils"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SetPendingTOTPSecret stores a new TOTP secret, encrypted, that takes effect
+// once EnableTOTP confirms it. It returns pgx.ErrNoRows if two-factor is
+// already enabled for the student.
+func (s *Storage) SetPendingTOTPSecret(ctx context.Context, studentID int, secret string) error {
+	sealed, err := utils.SealSecret([]byte(secret))
+	if err != nil {
+		return err
+	}
+
+	const query = `
+		UPDATE students
+		SET totp_secret_sealed = $2
+		WHERE id = $1 AND totp_enabled_at IS NULL;`
+
+	tag, err := s.pool.Exec(ctx, query, studentID, sealed)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// GetTOTPSecret returns the student's TOTP secret, which is empty if they
+// never enrolled, and whether it has been confirmed
+func (s *Storage) GetTOTPSecret(ctx context.Context, studentID int) (string, bool, error) {
+	const query = `
+		SELECT totp_secret_sealed, totp_enabled_at IS NOT NULL
+		FROM students
+		WHERE id = $1;`
+
+	var sealed []byte
+	var enabled bool
+	if err := s.pool.QueryRow(ctx, query, studentID).Scan(&sealed, &enabled); err != nil {
+		return "", false, err
+	}
+	if sealed == nil {
+		return "", enabled, nil
+	}
+
+	secret, err := utils.OpenSecret(sealed)
+	if err != nil {
+		return "", false, fmt.Errorf("decrypt TOTP secret (was JWT_SECRET changed?): %w", err)
+	}
+	return string(secret), enabled, nil
+}
+
+// UseTOTPStep records that the code for step was used. It returns false if
+// that step or a later one was already used, which means the code is a replay.
+func (s *Storage) UseTOTPStep(ctx context.Context, studentID int, step int64) (bool, error) {
+	const query = `
+		UPDATE students
+		SET totp_last_step = $2
+		WHERE id = $1 AND totp_last_step < $2;`
+
+	tag, err := s.pool.Exec(ctx, query, studentID, step)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// EnableTOTP turns on the pending secret after a first code from step was
+// checked, and stores the hashes of a fresh set of recovery codes
+func (s *Storage) EnableTOTP(ctx context.Context, studentID int, step int64, recoveryHashes []string) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	const query = `
+		UPDATE students
+		SET totp_enabled_at = NOW(), totp_last_step = $2
+		WHERE id = $1 AND totp_secret_sealed IS NOT NULL AND totp_enabled_at IS NULL;`
+
+	tag, err := tx.Exec(ctx, query, studentID, step)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, studentID, recoveryHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DisableTOTP removes the secret and recovery codes
+func (s *Storage) DisableTOTP(ctx context.Context, studentID int) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	const query = `
+		UPDATE students
+		SET totp_secret_sealed = NULL, totp_enabled_at = NULL, totp_last_step = 0
+		WHERE id = $1;`
+
+	if _, err := tx.Exec(ctx, query, studentID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM recovery_codes WHERE student_id = $1;`, studentID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ReplaceRecoveryCodes discards every recovery code of the student and
+// stores the new hashes
+func (s *Storage) ReplaceRecoveryCodes(ctx context.Context, studentID int, hashes []string) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := replaceRecoveryCodes(ctx, tx, studentID, hashes); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, studentID int, hashes []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM recovery_codes WHERE student_id = $1;`, studentID); err != nil {
+		return err
+	}
+
+	const query = `
+		INSERT INTO recovery_codes (student_id, code_hash)
+		SELECT $1, unnest($2::text[]);`
+
+	_, err := tx.Exec(ctx, query, studentID, hashes)
+	return err
+}
+
+// UseRecoveryCode marks an unused recovery code as used and reports whether
+// there was one
+func (s *Storage) UseRecoveryCode(ctx context.Context, studentID int, codeHash string) (bool, error) {
+	const query = `
+		UPDATE recovery_codes
+		SET used_at = NOW()
+		WHERE student_id = $1 AND code_hash = $2 AND used_at IS NULL;`
+
+	tag, err := s.pool.Exec(ctx, query, studentID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// SetTwoFactorRequired makes two-factor mandatory (or optional again) for a
+// student. Turning it on logs the student out everywhere, so sessions that
+// never passed a second factor do not outlive the requirement.
+func (s *Storage) SetTwoFactorRequired(ctx context.Context, studentID int, required bool) (*domain.Student, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var wasRequired bool
+	const current = `SELECT two_factor_required FROM students WHERE id = $1 FOR UPDATE;`
+	if err := tx.QueryRow(ctx, current, studentID).Scan(&wasRequired); err != nil {
+		return nil, err
+	}
+
+	const query = `UPDATE students SET two_factor_required = $2 WHERE id = $1 RETURNING ` + studentColumns + `;`
+	student, err := scanStudent(tx.QueryRow(ctx, query, studentID, required))
+	if err != nil {
+		return nil, err
+	}
+
+	if required && !wasRequired {
+		if _, err := revokeOtherSessions(ctx, tx, studentID, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	return student, tx.Commit(ctx)
+}
+
+func (s *Storage) CreateLoginChallenge(ctx context.Context, studentID int, tokenHash string, expiresAt time.Time) error {
+	const query = `
+		INSERT INTO login_challenges (student_id, token_hash, expires_at)
+		VALUES ($1, $2, $3);`
+
+	_, err := s.pool.Exec(ctx, query, studentID, tokenHash, expiresAt)
+	return err
+}
+
+// UseLoginChallenge counts one attempt against an open challenge and returns
+// its student. A challenge allows maxAttempts attempts; after that, or once
+// used or expired, it returns pgx.ErrNoRows.
+func (s *Storage) UseLoginChallenge(ctx context.Context, tokenHash string, maxAttempts int) (int, error) {
+	const query = `
+		UPDATE login_challenges
+		SET attempts = attempts + 1
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW() AND attempts < $2
+		RETURNING student_id;`
+
+	var studentID int
+	err := s.pool.QueryRow(ctx, query, tokenHash, maxAttempts).Scan(&studentID)
+	return studentID, err
+}
+
+// CompleteLoginChallenge closes a challenge once its second factor was
+// accepted; it returns pgx.ErrNoRows if another request closed it first
+func (s *Storage) CompleteLoginChallenge(ctx context.Context, tokenHash string) error {
+	const query = `
+		UPDATE login_challenges
+		SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL;`
+
+	tag, err := s.pool.Exec(ctx, query, tokenHash)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package postgres
+
+import (
+	"context"
+	"scheduler/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const watchColumns = `id, student_id, section_id, course_id, note, created_at`
+
+func scanWatchlistItem(row pgx.Row) (*domain.WatchlistItem, error) {
+	var w domain.WatchlistItem
+	err := row.Scan(
+		&w.ID,
+		&w.StudentID,
+		&w.SectionID,
+		&w.CourseID,
+		&w.Note,
+		&w.CreatedAt,
+	)
+	return &w, err
+}
+
+// GetWatchlist returns the student's watchlist with the sections each item
+// covers. The sections of all items are loaded with one querySectionDetails
+// call, however long the list is. A watched course without sections has an
+// empty section list.
+func (s *Storage) GetWatchlist(ctx context.Context, studentID int) ([]domain.WatchlistEntry, error) {
+	const query = `SELECT ` + watchColumns + ` FROM watchlist_items WHERE student_id = $1 ORDER BY created_at DESC, id;`
+
+	rows, err := s.pool.Query(ctx, query, studentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []domain.WatchlistEntry{}
+	var sectionIDs, courseIDs []int
+	for rows.Next() {
+		item, err := scanWatchlistItem(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, domain.WatchlistEntry{WatchlistItem: *item, Sections: []domain.WatchedSection{}})
+		if item.SectionID != nil {
+			sectionIDs = append(sectionIDs, *item.SectionID)
+		} else {
+			courseIDs = append(courseIDs, *item.CourseID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if len(entries) == 0 {
+		return entries, nil
+	}
+
+	const sectionsQuery = sectionDetailsQuery + `
+        WHERE s.id = ANY($1) OR s.course_id = ANY($2)
+        ORDER BY s.section_type, s.section_number
+	`
+
+	sections, err := s.querySectionDetails(ctx, sectionsQuery, sectionIDs, courseIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]domain.SectionWithDetails)
+	byCourse := make(map[int][]domain.SectionWithDetails)
+	for _, section := range sections {
+		byID[section.ID] = section
+		byCourse[section.CourseID] = append(byCourse[section.CourseID], section)
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+		if entry.SectionID != nil {
+			if section, ok := byID[*entry.SectionID]; ok {
+				entry.Sections = append(entry.Sections, domain.WatchedSection{SectionWithDetails: section})
+			}
+			continue
+		}
+		for _, section := range byCourse[*entry.CourseID] {
+			entry.Sections = append(entry.Sections, domain.WatchedSection{SectionWithDetails: section})
+		}
+	}
+
+	return entries, nil
+}
+
+func (s *Storage) GetWatchlistItem(ctx context.Context, studentID, itemID int) (*domain.WatchlistItem, error) {
+	const query = `SELECT ` + watchColumns + ` FROM watchlist_items WHERE id = $1 AND student_id = $2;`
+	return scanWatchlistItem(s.pool.QueryRow(ctx, query, itemID, studentID))
+}
+
+// AddToWatchlist watches a section or course; watching it again only
+// replaces the note when a new one is given
+func (s *Storage) AddToWatchlist(ctx context.Context, studentID int, req *domain.WatchRequest) (*domain.WatchlistItem, error) {
+	const sectionQuery = `
+		INSERT INTO watchlist_items (student_id, section_id, note)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (student_id, section_id) WHERE section_id IS NOT NULL
+		DO UPDATE SET note = COALESCE(EXCLUDED.note, watchlist_items.note)
+		RETURNING ` + watchColumns + `;`
+
+	const courseQuery = `
+		INSERT INTO watchlist_items (student_id, course_id, note)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (student_id, course_id) WHERE course_id IS NOT NULL
+		DO UPDATE SET note = COALESCE(EXCLUDED.note, watchlist_items.note)
+		RETURNING ` + watchColumns + `;`
+
+	if req.SectionID != nil {
+		return scanWatchlistItem(s.pool.QueryRow(ctx, sectionQuery, studentID, *req.SectionID, req.Note))
+	}
+	return scanWatchlistItem(s.pool.QueryRow(ctx, courseQuery, studentID, *req.CourseID, req.Note))
+}
+
+// UpdateWatchlistNote sets or clears an item's note; it returns
+// pgx.ErrNoRows if the item does not belong to the student
+func (s *Storage) UpdateWatchlistNote(ctx context.Context, studentID, itemID int, note *string) (*domain.WatchlistItem, error) {
+	const query = `
+		UPDATE watchlist_items
+		SET note = $3
+		WHERE id = $1 AND student_id = $2
+		RETURNING ` + watchColumns + `;`
+
+	return scanWatchlistItem(s.pool.QueryRow(ctx, query, itemID, studentID, note))
+}
+
+// RemoveFromWatchlist returns pgx.ErrNoRows if the item does not belong to the student
+func (s *Storage) RemoveFromWatchlist(ctx context.Context, studentID, itemID int) error {
+	const query = `DELETE FROM watchlist_items WHERE id = $1 AND student_id = $2;`
+
+	tag, err := s.pool.Exec(ctx, query, itemID, studentID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+// MoveWatchlistItem adds a watched section to a schedule and, unless keep is
+// set, removes the item from the watchlist, both in one transaction. It
+// returns pgx.ErrNoRows, adding nothing, if the item is already gone.
+func (s *Storage) MoveWatchlistItem(ctx context.Context, studentID, itemID, scheduleID, sectionID int, meetingID *int, keep bool) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := addSectionToSchedule(ctx, tx, scheduleID, sectionID, meetingID); err != nil {
+		return err
+	}
+
+	if !keep {
+		const query = `DELETE FROM watchlist_items WHERE id = $1 AND student_id = $2;`
+		tag, err := tx.Exec(ctx, query, itemID, studentID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return pgx.ErrNoRows
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetWatchlistEntry expands an item into the sections it covers
+func (s *Storage) GetWatchlistEntry(ctx context.Context, item domain.WatchlistItem) (*domain.WatchlistEntry, error) {
+	var sections []domain.SectionWithDetails
+	if item.SectionID != nil {
+		section, err := s.GetSectionDetails(ctx, *item.SectionID)
+		if err != nil {
+			return nil, err
+		}
+		sections = append(sections, *section)
+	} else {
+		var err error
+		sections, err = s.GetSectionsForCourse(ctx, *item.CourseID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	entry := &domain.WatchlistEntry{WatchlistItem: item, Sections: []domain.WatchedSection{}}
+	for _, section := range sections {
+		entry.Sections = append(entry.Sections, domain.WatchedSection{SectionWithDetails: section})
+	}
+
+	return entry, nil
+}
@@ -0,0 +1,79 @@
+// Package signing keeps the access token key ring in step with the keys
+// stored in the database and rotates them on schedule.
+package signing
+
+import (
+	"context"
+	"log"
+	"scheduler/internal/repository/postgres"
+	"scheduler/internal/utils"
+	"time"
+)
+
+// Rotator refreshes utils.Keys() from the database every Interval. When the
+// newest key is within Lead of retiring it creates the successor, which is
+// published in the JWKS for Lead before it signs anything so that verifiers
+// caching the key set see it first.
+type Rotator struct {
+	Storage   *postgres.Storage
+	Algorithm string
+	Interval  time.Duration
+	Lead      time.Duration
+}
+
+// NewRotator uses the algorithm from JWT_SIGNING_ALG
+func NewRotator(storage *postgres.Storage) *Rotator {
+	return &Rotator{
+		Storage:   storage,
+		Algorithm: utils.SigningAlgorithm(),
+		Interval:  5 * time.Minute,
+		Lead:      time.Hour,
+	}
+}
+
+// Run rotates every Interval until ctx is cancelled
+func (r *Rotator) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := r.RunOnce(ctx); err != nil {
+			log.Printf("signing keys: %v", err)
+		}
+	}
+}
+
+// RunOnce loads the keys, adding a successor if one is due, and installs
+// them in the key ring
+func (r *Rotator) RunOnce(ctx context.Context) error {
+	keys, err := r.Storage.RotateSigningKeys(ctx, func(latest *utils.SigningKey) (*utils.SigningKey, error) {
+		return r.successor(latest, time.Now())
+	})
+	if err != nil {
+		return err
+	}
+
+	utils.Keys().Replace(keys)
+	return nil
+}
+
+// successor returns the key to add after latest at now, or nil if none is due
+func (r *Rotator) successor(latest *utils.SigningKey, now time.Time) (*utils.SigningKey, error) {
+	switch {
+	case latest == nil || !latest.RetiresAt.After(now):
+		// First start, or rotation stalled long enough that nothing can sign
+		return utils.NewSigningKey(r.Algorithm, now)
+	case latest.RetiresAt.Sub(now) < r.Lead:
+		return utils.NewSigningKey(r.Algorithm, latest.RetiresAt)
+	case latest.Algorithm != r.Algorithm && latest.ActivatesAt.Before(now):
+		// JWT_SIGNING_ALG changed: switch over after the usual lead
+		return utils.NewSigningKey(r.Algorithm, now.Add(r.Lead))
+	}
+	return nil, nil
+}
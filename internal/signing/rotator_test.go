@@ -0,0 +1,57 @@
+package signing
+
+import (
+	"scheduler/internal/utils"
+	"testing"
+	"time"
+)
+
+func TestRotatorSuccessor(t *testing.T) {
+	now := time.Now()
+	r := &Rotator{Algorithm: utils.AlgEdDSA, Lead: time.Hour}
+
+	key := func(algorithm string, activatesAt, retiresAt time.Time) *utils.SigningKey {
+		return &utils.SigningKey{Kid: "k", Algorithm: algorithm, ActivatesAt: activatesAt, RetiresAt: retiresAt}
+	}
+
+	tests := []struct {
+		name   string
+		latest *utils.SigningKey
+		// want is when the successor activates; zero means no successor
+		want time.Time
+	}{
+		{"no key", nil, now},
+		{"retired", key(utils.AlgEdDSA, now.Add(-48*time.Hour), now.Add(-time.Minute)), now},
+		{"retires exactly now", key(utils.AlgEdDSA, now.Add(-48*time.Hour), now), now},
+		{"within lead", key(utils.AlgEdDSA, now.Add(-48*time.Hour), now.Add(30*time.Minute)), now.Add(30 * time.Minute)},
+		{"outside lead", key(utils.AlgEdDSA, now.Add(-48*time.Hour), now.Add(2*time.Hour)), time.Time{}},
+		{"algorithm changed", key(utils.AlgRS256, now.Add(-48*time.Hour), now.Add(48*time.Hour)), now.Add(time.Hour)},
+		{"algorithm changed, successor not active yet", key(utils.AlgRS256, now.Add(time.Minute), now.Add(48*time.Hour)), time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.successor(tt.latest, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.want.IsZero() {
+				if got != nil {
+					t.Fatalf("successor activates at %v, want none", got.ActivatesAt)
+				}
+				return
+			}
+
+			if got == nil {
+				t.Fatalf("no successor, want one activating at %v", tt.want)
+			}
+			if !got.ActivatesAt.Equal(tt.want) {
+				t.Errorf("successor activates at %v, want %v", got.ActivatesAt, tt.want)
+			}
+			if got.Algorithm != r.Algorithm {
+				t.Errorf("successor algorithm = %s, want %s", got.Algorithm, r.Algorithm)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package utils
+
+import "testing"
+
+func TestEnvInt(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"", 7},
+		{"12", 12},
+		{"0", 7},
+		{"-3", 7},
+		{"ten", 7},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("SCHEDULER_TEST_INT", tt.value)
+		if got := EnvInt("SCHEDULER_TEST_INT", 7); got != tt.want {
+			t.Errorf("EnvInt with %q = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
@@ -7,3 +7,14 @@ var ErrExpiredToken = errors.New("token has expired")
 var ErrNoRowsInserted = errors.New("no rows were inserted")
 var ErrUnauthorized = errors.New("unauthorized")
 var ErrValueConversion = errors.New("could not convert value")
+var ErrInvalidTransition = errors.New("invalid schedule status transition")
+var ErrSectionFull = errors.New("section has no available seats")
+var ErrTokenReused = errors.New("refresh token reused")
+var ErrInvalidCredentials = errors.New("invalid email or password")
+var ErrUnverifiedEmail = errors.New("email not verified by the identity provider")
+var ErrInvalidAPIKey = errors.New("invalid api key")
+var ErrInvalidStudentID = errors.New("student ID from the identity provider is longer than 20 characters")
+var ErrStudentIDTaken = errors.New("student ID already belongs to another account")
+var ErrNoPassword = errors.New("this account has no password yet; set one through password reset first")
+var ErrUnknownBuilding = errors.New("unknown building")
+var ErrPetitionPending = errors.New("this schedule already has a pending petition")
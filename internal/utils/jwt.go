@@ -3,66 +3,145 @@ package utils
 import (
 	"errors"
 	"os"
-	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type Claims struct {
+	UserID    int    `json:"user_id"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	SessionID int    `json:"sid"`
+	// Actor is set only on impersonation tokens and names the admin
+	// acting as the user
+	Actor *Actor `json:"act,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type Actor struct {
 	UserID int    `json:"user_id"`
 	Email  string `json:"email"`
-	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID int, email string) (string, error) {
-	expireHours := os.Getenv("JWT_EXPIRY_HOURS")
-	signingKey := os.Getenv("JWT_SECRET")
+// AccessTokenTTL reads JWT_ACCESS_MINUTES, defaulting to 15 minutes
+func AccessTokenTTL() time.Duration {
+	return time.Duration(EnvInt("JWT_ACCESS_MINUTES", 15)) * time.Minute
+}
+
+// RefreshTokenTTL reads REFRESH_TOKEN_DAYS, defaulting to 30 days
+func RefreshTokenTTL() time.Duration {
+	return time.Duration(EnvInt("REFRESH_TOKEN_DAYS", 30)) * 24 * time.Hour
+}
+
+// SessionMaxAge reads SESSION_MAX_DAYS, defaulting to 90 days. Refreshing
+// keeps a session going only until this long after the login.
+func SessionMaxAge() time.Duration {
+	return time.Duration(EnvInt("SESSION_MAX_DAYS", 90)) * 24 * time.Hour
+}
+
+// maxImpersonationTTL bounds IMPERSONATION_MINUTES so a misconfiguration
+// cannot hand out day-long tokens that act as a student
+const maxImpersonationTTL = time.Hour
+
+// ImpersonationTTL reads IMPERSONATION_MINUTES, defaulting to 15 minutes and
+// capped at an hour
+func ImpersonationTTL() time.Duration {
+	return min(time.Duration(EnvInt("IMPERSONATION_MINUTES", 15))*time.Minute, maxImpersonationTTL)
+}
 
-	if signingKey == "" {
-		return "", errors.New("JWT_SECRET not set")
+// TokenIssuer reads JWT_ISSUER, defaulting to "scheduler"
+func TokenIssuer() string {
+	if issuer := os.Getenv("JWT_ISSUER"); issuer != "" {
+		return issuer
 	}
+	return "scheduler"
+}
 
-	expirationHours, err := strconv.Atoi(expireHours)
+// TokenAudience reads JWT_AUDIENCE, defaulting to "scheduler-api"
+func TokenAudience() string {
+	if audience := os.Getenv("JWT_AUDIENCE"); audience != "" {
+		return audience
+	}
+	return "scheduler-api"
+}
 
+// GenerateToken issues a short-lived access token for a login session, signed
+// by the active key of the key ring. Every token gets a random jti so it can
+// be revoked on its own.
+func GenerateToken(userID int, email, role string, sessionID int) (string, *Claims, error) {
+	return signToken(&Claims{
+		UserID:    userID,
+		Email:     email,
+		Role:      role,
+		SessionID: sessionID,
+	}, time.Now().Add(AccessTokenTTL()))
+}
+
+// GenerateImpersonationToken issues an access token that lets actor act as
+// the user until expiresAt. It cannot be refreshed.
+func GenerateImpersonationToken(userID int, email, role string, sessionID int, actor Actor, expiresAt time.Time) (string, *Claims, error) {
+	return signToken(&Claims{
+		UserID:    userID,
+		Email:     email,
+		Role:      role,
+		SessionID: sessionID,
+		Actor:     &actor,
+	}, expiresAt)
+}
+
+func signToken(claims *Claims, expiresAt time.Time) (string, *Claims, error) {
+	now := time.Now()
+	key, err := keyRing.signer(now)
+	if err != nil {
+		return "", nil, err
+	}
+
+	jti, err := GenerateRandomToken(16)
 	if err != nil {
-		return "", errors.New("invalid JWT_EXPIRY_HOURS")
+		return "", nil, err
 	}
 
-	claims := Claims{
-		userID,
-		email,
-		jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expirationHours) * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        jti,
+		Issuer:    TokenIssuer(),
+		Audience:  jwt.ClaimStrings{TokenAudience()},
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+		IssuedAt:  jwt.NewNumericDate(now),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token := jwt.NewWithClaims(key.method(), claims)
+	token.Header["kid"] = key.Kid
 
-	tokenString, err := token.SignedString([]byte(signingKey))
+	tokenString, err := token.SignedString(key.Private)
 
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	return tokenString, nil
+	return tokenString, claims, nil
 }
 
+// ValidateToken accepts tokens signed by any unexpired key of the ring, with
+// the configured issuer and audience
 func ValidateToken(tokenString string) (*Claims, error) {
-	signingKey := os.Getenv("JWT_SECRET")
-
-	if signingKey == "" {
-		return nil, errors.New("JWT_SECRET not set")
-	}
-
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := keyRing.lookup(kid, time.Now())
+		if !ok || token.Method.Alg() != key.Algorithm {
 			return nil, ErrInvalidToken
 		}
-		return []byte(signingKey), nil
-	})
-
+		return key.Private.Public(), nil
+	},
+		jwt.WithValidMethods([]string{AlgEdDSA, AlgRS256}),
+		jwt.WithIssuer(TokenIssuer()),
+		jwt.WithAudience(TokenAudience()),
+		jwt.WithExpirationRequired(),
+	)
+
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, ErrExpiredToken
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -72,9 +151,5 @@ func ValidateToken(tokenString string) (*Claims, error) {
 		return nil, ErrInvalidToken
 	}
 
-	if claims.ExpiresAt != nil && claims.ExpiresAt.Before(time.Now()) {
-		return nil, ErrExpiredToken
-	}
-
 	return claims, nil
 }
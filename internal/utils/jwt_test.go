@@ -0,0 +1,189 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// useKeys installs keys in the key ring for the length of the test
+func useKeys(t *testing.T, keys ...*SigningKey) {
+	t.Helper()
+
+	Keys().Replace(keys)
+	t.Cleanup(func() { Keys().Replace(nil) })
+}
+
+func newTestKey(t *testing.T, algorithm string, activatesAt time.Time) *SigningKey {
+	t.Helper()
+
+	key, err := NewSigningKey(algorithm, activatesAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestValidateToken(t *testing.T) {
+	for _, algorithm := range []string{AlgEdDSA, AlgRS256} {
+		t.Run(algorithm, func(t *testing.T) {
+			useKeys(t, newTestKey(t, algorithm, time.Now().Add(-time.Minute)))
+
+			token, _, err := GenerateToken(1, "a@nu.edu.kz", "student", 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			claims, err := ValidateToken(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.UserID != 1 || claims.SessionID != 2 {
+				t.Errorf("claims = %+v, want user 1 and session 2", claims)
+			}
+		})
+	}
+}
+
+func TestValidateTokenUnknownKid(t *testing.T) {
+	useKeys(t, newTestKey(t, AlgEdDSA, time.Now().Add(-time.Minute)))
+
+	token, _, err := GenerateToken(1, "a@nu.edu.kz", "student", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The signing key has left the ring
+	Keys().Replace([]*SigningKey{newTestKey(t, AlgEdDSA, time.Now().Add(-time.Minute))})
+
+	if _, err := ValidateToken(token); err == nil {
+		t.Fatal("token signed by a key outside the ring was accepted")
+	}
+}
+
+func TestValidateTokenWrongAlgorithm(t *testing.T) {
+	key := newTestKey(t, AlgEdDSA, time.Now().Add(-time.Minute))
+	useKeys(t, key)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An RS256 token naming the EdDSA key must not be checked against it
+	claims := &Claims{UserID: 1, RegisteredClaims: jwt.RegisteredClaims{
+		Issuer:    TokenIssuer(),
+		Audience:  jwt.ClaimStrings{TokenAudience()},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = key.Kid
+	signed, err := token.SignedString(rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ValidateToken(signed); err == nil {
+		t.Fatal("token with the wrong algorithm for its kid was accepted")
+	}
+
+	none := jwt.NewWithClaims(jwt.SigningMethodNone, claims)
+	none.Header["kid"] = key.Kid
+	unsigned, err := none.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ValidateToken(unsigned); err == nil {
+		t.Fatal("unsigned token was accepted")
+	}
+}
+
+func TestValidateTokenIssuerAndAudience(t *testing.T) {
+	useKeys(t, newTestKey(t, AlgEdDSA, time.Now().Add(-time.Minute)))
+
+	token, _, err := GenerateToken(1, "a@nu.edu.kz", "student", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("wrong issuer", func(t *testing.T) {
+		t.Setenv("JWT_ISSUER", "someone-else")
+		if _, err := ValidateToken(token); err == nil {
+			t.Fatal("token from another issuer was accepted")
+		}
+	})
+
+	t.Run("wrong audience", func(t *testing.T) {
+		t.Setenv("JWT_AUDIENCE", "another-api")
+		if _, err := ValidateToken(token); err == nil {
+			t.Fatal("token for another audience was accepted")
+		}
+	})
+}
+
+func TestValidateTokenExpired(t *testing.T) {
+	useKeys(t, newTestKey(t, AlgEdDSA, time.Now().Add(-time.Minute)))
+
+	token, _, err := signToken(&Claims{UserID: 1}, time.Now().Add(-time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ValidateToken(token); !errors.Is(err, ErrExpiredToken) {
+		t.Fatalf("err = %v, want ErrExpiredToken", err)
+	}
+}
+
+func TestValidateTokenRetiredKey(t *testing.T) {
+	now := time.Now()
+	retired := newTestKey(t, AlgEdDSA, now.Add(-2*time.Hour))
+	useKeys(t, retired)
+
+	token, _, err := GenerateToken(1, "a@nu.edu.kz", "student", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The key has stopped signing but is still within its grace period
+	retired.RetiresAt = now.Add(-time.Minute)
+	retired.ExpiresAt = now.Add(time.Minute)
+	successor := newTestKey(t, AlgEdDSA, retired.RetiresAt)
+	Keys().Replace([]*SigningKey{retired, successor})
+
+	if _, err := ValidateToken(token); err != nil {
+		t.Fatalf("token signed by a retired but unexpired key was rejected: %v", err)
+	}
+
+	fresh, _, err := GenerateToken(1, "a@nu.edu.kz", "student", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(fresh, &Claims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Header["kid"] != successor.Kid {
+		t.Errorf("new token signed by %v, want successor %s", parsed.Header["kid"], successor.Kid)
+	}
+
+	// Once it expires its tokens are no longer trusted
+	retired.ExpiresAt = now.Add(-time.Second)
+	if _, err := ValidateToken(token); err == nil {
+		t.Fatal("token signed by an expired key was accepted")
+	}
+}
+
+func TestSigningKeyOutlivesImpersonationTokens(t *testing.T) {
+	t.Setenv("JWT_ACCESS_MINUTES", "5")
+	t.Setenv("IMPERSONATION_MINUTES", "45")
+
+	key := newTestKey(t, AlgEdDSA, time.Now())
+	if grace := key.ExpiresAt.Sub(key.RetiresAt); grace < 45*time.Minute {
+		t.Errorf("key trusted %v after retiring, want at least the 45m impersonation lifetime", grace)
+	}
+}
@@ -0,0 +1,277 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported signing algorithms
+const (
+	AlgEdDSA = "EdDSA"
+	AlgRS256 = "RS256"
+)
+
+var ErrNoSigningKey = errors.New("no active signing key")
+
+// SigningKey is one key of the ring. It is published from the moment it is
+// created, signs new tokens from ActivatesAt until RetiresAt, and keeps
+// verifying tokens it signed until ExpiresAt.
+type SigningKey struct {
+	Kid         string
+	Algorithm   string
+	Private     crypto.Signer
+	ActivatesAt time.Time
+	RetiresAt   time.Time
+	ExpiresAt   time.Time
+}
+
+func (k *SigningKey) method() jwt.SigningMethod {
+	if k.Algorithm == AlgRS256 {
+		return jwt.SigningMethodRS256
+	}
+	return jwt.SigningMethodEdDSA
+}
+
+// SigningAlgorithm reads JWT_SIGNING_ALG, defaulting to EdDSA
+func SigningAlgorithm() string {
+	if os.Getenv("JWT_SIGNING_ALG") == AlgRS256 {
+		return AlgRS256
+	}
+	return AlgEdDSA
+}
+
+// KeyRotationPeriod reads JWT_KEY_ROTATION_DAYS, defaulting to 30 days
+func KeyRotationPeriod() time.Duration {
+	return time.Duration(EnvInt("JWT_KEY_ROTATION_DAYS", 30)) * 24 * time.Hour
+}
+
+// NewSigningKey generates a key that signs from activatesAt for one rotation
+// period and is trusted for the longest token lifetime after that, so the
+// access or impersonation tokens it signed last stay valid until they expire
+func NewSigningKey(algorithm string, activatesAt time.Time) (*SigningKey, error) {
+	var private crypto.Signer
+	var err error
+	switch algorithm {
+	case AlgEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	case AlgRS256:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	kid, err := GenerateRandomToken(12)
+	if err != nil {
+		return nil, err
+	}
+
+	retiresAt := activatesAt.Add(KeyRotationPeriod())
+	return &SigningKey{
+		Kid:         kid,
+		Algorithm:   algorithm,
+		Private:     private,
+		ActivatesAt: activatesAt,
+		RetiresAt:   retiresAt,
+		ExpiresAt:   retiresAt.Add(max(AccessTokenTTL(), ImpersonationTTL()) + time.Minute),
+	}, nil
+}
+
+// KeyRing holds the keys currently in use. The rotation worker replaces its
+// contents; token signing and validation only read it.
+type KeyRing struct {
+	mu   sync.RWMutex
+	keys []*SigningKey // ordered by ActivatesAt
+}
+
+var keyRing = &KeyRing{}
+
+// Keys returns the process-wide key ring used by GenerateToken and
+// ValidateToken
+func Keys() *KeyRing {
+	return keyRing
+}
+
+// Replace swaps in a new set of keys, dropping any that have expired
+func (r *KeyRing) Replace(keys []*SigningKey) {
+	now := time.Now()
+	live := make([]*SigningKey, 0, len(keys))
+	for _, k := range keys {
+		if k.ExpiresAt.After(now) {
+			live = append(live, k)
+		}
+	}
+	sort.Slice(live, func(i, j int) bool { return live[i].ActivatesAt.Before(live[j].ActivatesAt) })
+
+	r.mu.Lock()
+	r.keys = live
+	r.mu.Unlock()
+}
+
+// Latest returns the key that activates last, which may not be active yet
+func (r *KeyRing) Latest() *SigningKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.keys) == 0 {
+		return nil
+	}
+	return r.keys[len(r.keys)-1]
+}
+
+// signer returns the most recently activated key
+func (r *KeyRing) signer(now time.Time) (*SigningKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for i := len(r.keys) - 1; i >= 0; i-- {
+		if !r.keys[i].ActivatesAt.After(now) {
+			return r.keys[i], nil
+		}
+	}
+	return nil, ErrNoSigningKey
+}
+
+func (r *KeyRing) lookup(kid string, now time.Time) (*SigningKey, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, k := range r.keys {
+		if k.Kid == kid && k.ExpiresAt.After(now) {
+			return k, true
+		}
+	}
+	return nil, false
+}
+
+// JWK is the public half of a signing key in RFC 7517 form
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of every key in the ring, including ones not
+// active yet so verifiers can cache them before they are used
+func (r *KeyRing) JWKS() JWKSet {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, k := range r.keys {
+		jwk := JWK{Kid: k.Kid, Use: "sig", Alg: k.Algorithm}
+		switch public := k.Private.Public().(type) {
+		case ed25519.PublicKey:
+			jwk.Kty, jwk.Crv, jwk.X = "OKP", "Ed25519", base64.RawURLEncoding.EncodeToString(public)
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// keyEncryptionKey derives the AES key that protects private keys and other
+// secrets at rest from JWT_SECRET
+func keyEncryptionKey() ([]byte, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return nil, errors.New("JWT_SECRET not set")
+	}
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:], nil
+}
+
+// SealSecret encrypts plaintext with AES-GCM under a key derived from
+// JWT_SECRET, for secrets that are stored in the database
+func SealSecret(plaintext []byte) ([]byte, error) {
+	gcm, err := keyCipher()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// OpenSecret reverses SealSecret. It fails if JWT_SECRET has changed since.
+func OpenSecret(sealed []byte) ([]byte, error) {
+	gcm, err := keyCipher()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("sealed secret too short")
+	}
+	return gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+}
+
+// SealPrivateKey encodes a private key as PKCS #8 and seals it with
+// SealSecret
+func SealPrivateKey(key crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return SealSecret(der)
+}
+
+// OpenPrivateKey reverses SealPrivateKey
+func OpenPrivateKey(sealed []byte) (crypto.Signer, error) {
+	der, err := OpenSecret(sealed)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt signing key (was JWT_SECRET changed?): %w", err)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("signing key is not a signer")
+	}
+	return signer, nil
+}
+
+func keyCipher() (cipher.AEAD, error) {
+	kek, err := keyEncryptionKey()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,43 @@
+package utils
+
+import "time"
+
+// LoginPolicy controls how failed logins are throttled. Failures are counted
+// separately per account email and per client IP; once a counter reaches its
+// limit every further failure doubles the lockout, up to MaxLockout.
+type LoginPolicy struct {
+	MaxAccountFailures int
+	MaxIPFailures      int
+	BaseLockout        time.Duration
+	MaxLockout         time.Duration
+	// Counters start over once no failure has been seen for this long
+	Window time.Duration
+}
+
+// LoginPolicyFromEnv reads LOGIN_MAX_FAILURES (default 5),
+// LOGIN_IP_MAX_FAILURES (20), LOGIN_LOCKOUT_SECONDS (30),
+// LOGIN_MAX_LOCKOUT_MINUTES (15) and LOGIN_FAILURE_WINDOW_MINUTES (60)
+func LoginPolicyFromEnv() LoginPolicy {
+	return LoginPolicy{
+		MaxAccountFailures: EnvInt("LOGIN_MAX_FAILURES", 5),
+		MaxIPFailures:      EnvInt("LOGIN_IP_MAX_FAILURES", 20),
+		BaseLockout:        time.Duration(EnvInt("LOGIN_LOCKOUT_SECONDS", 30)) * time.Second,
+		MaxLockout:         time.Duration(EnvInt("LOGIN_MAX_LOCKOUT_MINUTES", 15)) * time.Minute,
+		Window:             time.Duration(EnvInt("LOGIN_FAILURE_WINDOW_MINUTES", 60)) * time.Minute,
+	}
+}
+
+// Lockout returns how long to lock a counter that has reached failures, or 0
+// if it is still under limit
+func (p LoginPolicy) Lockout(failures, limit int) time.Duration {
+	if failures < limit {
+		return 0
+	}
+
+	lockout := p.BaseLockout
+	for i := limit; i < failures && lockout < p.MaxLockout; i++ {
+		lockout *= 2
+	}
+
+	return min(lockout, p.MaxLockout)
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginPolicyLockout(t *testing.T) {
+	policy := LoginPolicy{BaseLockout: 30 * time.Second, MaxLockout: 15 * time.Minute}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{4, 0},
+		{5, 30 * time.Second},
+		{6, time.Minute},
+		{7, 2 * time.Minute},
+		{9, 8 * time.Minute},
+		{10, 15 * time.Minute},
+		{1000, 15 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := policy.Lockout(tt.failures, 5); got != tt.want {
+			t.Errorf("Lockout(%d, 5) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLoginPolicyLockoutBaseAboveMax(t *testing.T) {
+	policy := LoginPolicy{BaseLockout: time.Hour, MaxLockout: 15 * time.Minute}
+
+	if got := policy.Lockout(1, 1); got != 15*time.Minute {
+		t.Errorf("Lockout(1, 1) = %v, want %v", got, 15*time.Minute)
+	}
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// The SHA-1 seed of RFC 6238 appendix B, "12345678901234567890", in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// RFC 6238 appendix B SHA-1 vectors, cut to the last six of their eight digits
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestTOTPCode(t *testing.T) {
+	key := []byte("12345678901234567890")
+
+	for _, tt := range rfcVectors {
+		if got := totpCode(key, tt.unix/totpPeriod); got != tt.code {
+			t.Errorf("totpCode at %d = %s, want %s", tt.unix, got, tt.code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	for _, tt := range rfcVectors {
+		step, ok := ValidateTOTP(rfcSecret, tt.code, time.Unix(tt.unix, 0))
+		if !ok || step != tt.unix/totpPeriod {
+			t.Errorf("ValidateTOTP at %d = %d, %v, want step %d", tt.unix, step, ok, tt.unix/totpPeriod)
+		}
+	}
+
+	// 1111111109 and 1111111111 are one step apart
+	const at, code = 1111111111, "050471"
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		now    int64
+		want   bool
+	}{
+		{"lowercase secret", "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", code, at, true},
+		{"one step early", rfcSecret, code, at - totpPeriod, true},
+		{"one step late", rfcSecret, code, at + totpPeriod, true},
+		{"two steps early", rfcSecret, code, at - 2*totpPeriod, false},
+		{"two steps late", rfcSecret, code, at + 2*totpPeriod, false},
+		{"short code", rfcSecret, code[1:], at, false},
+		{"long code", rfcSecret, "0" + code, at, false},
+		{"eight digit code", rfcSecret, "14050471", at, false},
+		{"empty code", rfcSecret, "", at, false},
+		{"invalid secret", "not base32!", code, at, false},
+	}
+
+	for _, tt := range tests {
+		if _, ok := ValidateTOTP(tt.secret, tt.code, time.Unix(tt.now, 0)); ok != tt.want {
+			t.Errorf("%s: valid = %v, want %v", tt.name, ok, tt.want)
+		}
+	}
+}